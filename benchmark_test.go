@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"testing"
+)
+
+type benchLabels struct {
+	Method   string `label:"method"`
+	Endpoint string `label:"endpoint"`
+	Status   int    `label:"status"`
+	Cached   bool   `label:"cached"`
+}
+
+var benchLabelValues = benchLabels{Method: "GET", Endpoint: "/api/users/{id}", Status: 200, Cached: true}
+
+func BenchmarkCounterInc(b *testing.B) {
+	c := Counter("bench_total", "Benchmark.", WithRegistry(NewRegistry()))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Inc()
+	}
+}
+
+func BenchmarkCounterWithInc(b *testing.B) {
+	c := CounterWith[benchLabels]("bench_total", "Benchmark.", WithRegistry(NewRegistry()))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Inc(benchLabelValues)
+	}
+}
+
+func BenchmarkCounterWithIncPointer(b *testing.B) {
+	c := CounterWith[*benchLabels]("bench_total", "Benchmark.", WithRegistry(NewRegistry()))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Inc(&benchLabelValues)
+	}
+}
+
+func BenchmarkCounterWithBound(b *testing.B) {
+	c := CounterWith[benchLabels]("bench_total", "Benchmark.", WithRegistry(NewRegistry()))
+	bound := c.With(benchLabelValues)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bound.Inc()
+	}
+}
+
+func BenchmarkGaugeWithSet(b *testing.B) {
+	g := GaugeWith[benchLabels]("bench_gauge", "Benchmark.", WithRegistry(NewRegistry()))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g.Set(float64(i), benchLabelValues)
+	}
+}
+
+func BenchmarkHistogramWithObserve(b *testing.B) {
+	h := HistogramWith[benchLabels]("bench_seconds", "Benchmark.", durationBuckets, WithRegistry(NewRegistry()))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.Observe(0.042, benchLabelValues)
+	}
+}
+
+func BenchmarkHistogramWithBound(b *testing.B) {
+	h := HistogramWith[benchLabels]("bench_seconds", "Benchmark.", durationBuckets, WithRegistry(NewRegistry()))
+	bound := h.With(benchLabelValues)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bound.Observe(0.042)
+	}
+}
+
+func BenchmarkCounterWithIncParallel(b *testing.B) {
+	c := CounterWith[benchLabels]("bench_total", "Benchmark.", WithRegistry(NewRegistry()))
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Inc(benchLabelValues)
+		}
+	})
+}
+
+func TestLabeledMetricsDoNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are unreliable with the race detector")
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[benchLabels]("alloc_total", "Allocations.", WithRegistry(reg))
+	g := GaugeWith[*benchLabels]("alloc_gauge", "Allocations.", WithRegistry(reg))
+	h := HistogramWith[benchLabels]("alloc_seconds", "Allocations.", durationBuckets, WithRegistry(reg))
+
+	testCases := []struct {
+		name string
+		fn   func()
+	}{
+		{name: "counter", fn: func() { c.Inc(benchLabelValues) }},
+		{name: "gauge pointer labels", fn: func() { g.Set(1, &benchLabelValues) }},
+		{name: "histogram", fn: func() { h.Observe(1, benchLabelValues) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn() // Create the series first.
+			if allocs := testing.AllocsPerRun(100, tc.fn); allocs != 0 {
+				t.Errorf("expected no allocations, got %v", allocs)
+			}
+		})
+	}
+}
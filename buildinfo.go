@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"slices"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// buildInfoLabels defines the labels of the build_info metric, read from debug.ReadBuildInfo.
+type buildInfoLabels struct {
+	GoVersion string `label:"go_version"`
+	Path      string `label:"path"`
+	Version   string `label:"version"`
+	Revision  string `label:"revision"`
+	Dirty     bool   `label:"dirty"`
+}
+
+// readBuildInfo returns the labels of the build_info metric for the running binary.
+func readBuildInfo() buildInfoLabels {
+	labels := buildInfoLabels{GoVersion: runtime.Version()}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return labels
+	}
+	labels.Path = bi.Main.Path
+	labels.Version = bi.Main.Version
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			labels.Revision = s.Value
+		case "vcs.modified":
+			labels.Dirty = s.Value == "true"
+		}
+	}
+	return labels
+}
+
+// BuildInfo registers a build_info info metric with the Go version, the path and version of
+// the main module, the VCS revision and whether the working tree was dirty (modified) at build
+// time, as reported by runtime/debug.ReadBuildInfo, e.g.:
+//
+//	build_info{dirty="false",go_version="go1.24.2",path="github.com/org/app",revision="2f1e6c1...",version="v1.2.3"} 1
+//
+// Call it once, e.g. at the start of main.
+func BuildInfo(opts ...Option) {
+	BuildInfoWith(struct{}{}, opts...)
+}
+
+// BuildInfoWith registers a build_info info metric like BuildInfo, with extra typed labels,
+// e.g. the environment or the deployment region.
+func BuildInfoWith[T any](labels T, opts ...Option) {
+	if err := NewBuildInfoWith(labels, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// NewBuildInfoWith registers a build_info info metric like BuildInfoWith, or returns an error
+// if the extra labels are invalid or the metric can't be registered
+func NewBuildInfoWith[T any](labels T, opts ...Option) error {
+	o := newMetricOpts(opts)
+	name := o.registry.fqName("build_info")
+
+	build, err := getLabelInfo[buildInfoLabels]()
+	if err != nil {
+		return err
+	}
+	extra, err := getLabelInfo[T]()
+	if err != nil {
+		return err
+	}
+	for _, key := range extra.keys {
+		if slices.Contains(build.keys, key) {
+			return fmt.Errorf("label %q is already defined by the build_info metric", key)
+		}
+	}
+
+	keys := slices.Concat(build.keys, extra.keys)
+	if err := o.validate(dto.MetricType_GAUGE, name, keys); err != nil {
+		return err
+	}
+
+	buildLabels := readBuildInfo()
+	values := labelValues(build, nil, &buildLabels)
+	values = labelValues(extra, values, &labels)
+	if !extra.allow(values[len(build.keys):], o.labelValuePolicy == LabelValuesReject) {
+		return fmt.Errorf("invalid labels of info metric %s: label values not allowed by the `values` tag option", name)
+	}
+
+	_, err = registerInfo(o, name, "Build information about the main Go module.", keys, values)
+	return err
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	type deployLabels struct {
+		Environment string `label:"environment"`
+	}
+
+	reg := NewRegistry()
+	BuildInfoWith(deployLabels{Environment: "production"}, WithRegistry(reg))
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		"# TYPE build_info gauge",
+		`environment="production"`,
+		`go_version="` + runtime.Version() + `"`,
+		`dirty="`,
+		`revision="`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+
+	if om := scrapeOpenMetrics(t, reg.Handler()); !strings.Contains(om, "# TYPE build info\n") {
+		t.Errorf("expected info type in OpenMetrics output:\n%s", om)
+	}
+
+	// Extra labels must not clash with the build labels.
+	type versionLabels struct {
+		Version string `label:"version"`
+	}
+	if err := NewBuildInfoWith(versionLabels{Version: "v1"}, WithRegistry(NewRegistry())); err == nil {
+		t.Error("expected error for clashing label")
+	}
+
+	// The build info is registered once per registry.
+	if err := NewBuildInfoWith(struct{}{}, WithRegistry(reg)); err == nil {
+		t.Error("expected error for duplicate build_info metric")
+	}
+}
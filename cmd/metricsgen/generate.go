@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricsPath is the import path of the package of the generated methods.
+const metricsPath = "github.com/go-chi/metrics"
+
+var (
+	stringerType = newInterface("String", types.Typ[types.String])
+
+	textMarshalerType = newInterface("MarshalText", types.NewSlice(types.Typ[types.Byte]), types.Universe.Lookup("error").Type())
+)
+
+// newInterface returns an interface with a single method without parameters.
+func newInterface(method string, results ...types.Type) *types.Interface {
+	vars := make([]*types.Var, len(results))
+	for i, typ := range results {
+		vars[i] = types.NewParam(token.NoPos, nil, "", typ)
+	}
+	sig := types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(vars...), false)
+	return types.NewInterfaceType([]*types.Func{types.NewFunc(token.NoPos, nil, method, sig)}, nil).Complete()
+}
+
+// labelField is a label struct field with the expression formatting its label value.
+type labelField struct {
+	tag   string
+	value string
+}
+
+// generator writes the generated methods of label structs.
+type generator struct {
+	pkg *types.Package
+
+	// imports maps the import paths used by the generated code to package names.
+	imports map[string]string
+}
+
+// generate type-checks the package in dir, ignoring the output file, and returns the
+// generated methods of the named label struct types.
+func generate(dir, outputName string, typeNames, args []string) ([]byte, error) {
+	pkg, err := loadPackage(dir, outputName)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, imports: map[string]string{metricsPath: "metrics"}}
+	var body bytes.Buffer
+	for _, name := range typeNames {
+		st, fields, err := g.labelFields(name)
+		if err != nil {
+			return nil, err
+		}
+		g.writeMethods(&body, name, st, fields)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"metricsgen %s\"; DO NOT EDIT.\n\n", strings.Join(args, " "))
+	fmt.Fprintf(&buf, "package %s\n\n", pkg.Name())
+	buf.WriteString("import (\n")
+	var std, other []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	for _, path := range std {
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+	if len(std) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, path := range other {
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// qualifier returns the package name to qualify types of other packages with,
+// and records the import of the package.
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	g.imports[p.Path()] = p.Name()
+	return p.Name()
+}
+
+// loadPackage parses and type-checks the non-test Go files of the package in dir.
+func loadPackage(dir, outputName string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		// Previously generated methods may be out of date.
+		if name == outputName {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	var errs []error
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+	pkg, _ := conf.Check(bp.ImportPath, fset, files, nil)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("type-checking package %s: %w", bp.Name, errors.Join(errs...))
+	}
+	return pkg, nil
+}
+
+// labelFields returns the label fields of the named label struct type.
+func (g *generator) labelFields(name string) (*types.Struct, []labelField, error) {
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, nil, fmt.Errorf("type %s not found in package %s", name, g.pkg.Name())
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid label type %s: %s (must be struct)", name, obj.Type().Underlying())
+	}
+	if _, ok := obj.Type().(*types.Named); !ok || obj.IsAlias() {
+		return nil, nil, fmt.Errorf("invalid label type %s: must be a defined struct type to have methods", name)
+	}
+	fields, err := g.addFields(nil, name, "l", st)
+	return st, fields, err
+}
+
+// addFields appends the label fields of the struct type, accessed by the expression x, to fields.
+// Anonymous embedded structs without a `label` tag are flattened into the label set, and fields
+// tagged with `label:"-"` are skipped, like the metrics package does.
+func (g *generator) addFields(fields []labelField, structName, x string, st *types.Struct) ([]labelField, error) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		fieldExpr := x + "." + field.Name()
+		errorf := func(format string, args ...any) error {
+			return fmt.Errorf("field %s of label struct %s: "+format, append([]any{field.Name(), structName}, args...)...)
+		}
+
+		labelTag := reflect.StructTag(st.Tag(i)).Get("label")
+		if labelTag == "-" {
+			continue
+		}
+		if labelTag == "" && field.Embedded() {
+			if embedded, ok := field.Type().Underlying().(*types.Struct); ok {
+				if !field.Exported() {
+					return nil, errorf("embedded label structs must be exported")
+				}
+				var err error
+				if fields, err = g.addFields(fields, structName, fieldExpr, embedded); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if labelTag == "" {
+			return nil, errorf("missing `label` struct tag")
+		}
+		if !field.Exported() {
+			return nil, errorf("label struct fields must be exported")
+		}
+
+		value, err := g.valueExpr(fieldExpr, field.Type())
+		if err != nil {
+			return nil, errorf("%v", err)
+		}
+		fields = append(fields, labelField{tag: labelTag, value: value})
+	}
+	return fields, nil
+}
+
+// valueExpr returns the expression formatting the field value x as a label value,
+// like the metrics package formats label values by reflection.
+func (g *generator) valueExpr(x string, t types.Type) (string, error) {
+	unsupported := fmt.Errorf("unsupported label type %s: must be string, int, uint, bool, fmt.Stringer or encoding.TextMarshaler", types.TypeString(t, types.RelativeTo(g.pkg)))
+
+	switch t.Underlying().(type) {
+	case *types.Interface, *types.Pointer:
+		// Nil values can't be formatted.
+		return "", unsupported
+	}
+
+	switch {
+	case types.Implements(t, textMarshalerType):
+		return "metrics.LabelText(" + x + ")", nil
+	case types.Implements(t, stringerType):
+		return x + ".String()", nil
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", unsupported
+	}
+	info := basic.Info()
+	switch {
+	case info&types.IsString != 0:
+		if types.Identical(t, types.Typ[types.String]) {
+			return x, nil
+		}
+		return "string(" + x + ")", nil
+	case info&types.IsUnsigned != 0:
+		return "metrics.LabelUint(uint64(" + x + "))", nil
+	case info&types.IsInteger != 0:
+		return "metrics.LabelInt(int64(" + x + "))", nil
+	case info&types.IsBoolean != 0:
+		g.imports["strconv"] = "strconv"
+		if types.Identical(t, types.Typ[types.Bool]) {
+			return "strconv.FormatBool(" + x + ")", nil
+		}
+		return "strconv.FormatBool(bool(" + x + "))", nil
+	}
+	return "", unsupported
+}
+
+// writeMethods writes the metrics.GeneratedLabels methods of the named label struct type st.
+func (g *generator) writeMethods(w *bytes.Buffer, name string, st *types.Struct, fields []labelField) {
+	fmt.Fprintf(w, "\nvar _ metrics.GeneratedLabels[%s] = %s{}\n", name, name)
+
+	// Struct conversions ignore tags, but require the same field names and types.
+	// Changed tags are detected by the metrics package, which compares them with LabelTags.
+	fmt.Fprintf(w, "\n// The methods of %s are out of date if it doesn't convert to the struct\n", name)
+	fmt.Fprintf(w, "// they were generated for, or its `label` tags changed. Run go generate again.\n")
+	vars := make([]*types.Var, st.NumFields())
+	for i := range vars {
+		vars[i] = st.Field(i)
+	}
+	fmt.Fprintf(w, "func _(l %s) {\n\t_ = %s(l)\n}\n", name, types.TypeString(types.NewStruct(vars, nil), g.qualifier))
+
+	fmt.Fprintf(w, "\n// LabelStruct returns the label struct itself.\n")
+	fmt.Fprintf(w, "func (l %s) LabelStruct() %s {\n\treturn l\n}\n", name, name)
+
+	fmt.Fprintf(w, "\n// LabelTags returns the `label` struct tags of %s.\n", name)
+	fmt.Fprintf(w, "func (%s) LabelTags() []string {\n", name)
+	fmt.Fprintf(w, "\treturn []string{\n")
+	for _, f := range fields {
+		fmt.Fprintf(w, "\t\t%s,\n", strconv.Quote(f.tag))
+	}
+	fmt.Fprintf(w, "\t}\n}\n")
+
+	fmt.Fprintf(w, "\n// AppendLabelValues appends the label values of %s to dst, in the order of LabelTags.\n", name)
+	fmt.Fprintf(w, "func (l %s) AppendLabelValues(dst []string) []string {\n", name)
+	if len(fields) == 0 {
+		fmt.Fprintf(w, "\treturn dst\n}\n")
+		return
+	}
+	fmt.Fprintf(w, "\treturn append(dst,\n")
+	for _, f := range fields {
+		fmt.Fprintf(w, "\t\t%s,\n", f.value)
+	}
+	fmt.Fprintf(w, "\t)\n}\n")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	src, err := generate("internal/testlabels", "requestlabels_labels.go", []string{"RequestLabels", "CommonLabels", "BenchLabels"}, []string{"-type=RequestLabels,CommonLabels,BenchLabels"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := format.Source(src)
+	if err != nil {
+		t.Fatalf("invalid generated code: %v\n%s", err, src)
+	}
+
+	want, err := os.ReadFile("internal/testlabels/requestlabels_labels.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code is out of date, run go generate ./...:\n%s", got)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := `package bad
+
+import "time"
+
+type missingTag struct {
+	Name string
+}
+
+type unexported struct {
+	name string ` + "`label:\"name\"`" + `
+}
+
+type unsupported struct {
+	Timeout  time.Duration ` + "`label:\"timeout\"`" + ` // fmt.Stringer
+	Duration float64       ` + "`label:\"duration\"`" + `
+}
+
+type pointer struct {
+	Name *string ` + "`label:\"name\"`" + `
+}
+
+type notStruct string
+`
+	if err := os.WriteFile(filepath.Join(dir, "bad.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		typ string
+		err string
+	}{
+		{"missingTag", "field Name of label struct missingTag: missing `label` struct tag"},
+		{"unexported", "field name of label struct unexported: label struct fields must be exported"},
+		{"unsupported", "field Duration of label struct unsupported: unsupported label type float64"},
+		{"pointer", "unsupported label type *string"},
+		{"notStruct", "invalid label type notStruct: string (must be struct)"},
+		{"missing", "type missing not found in package bad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			_, err := generate(dir, "bad_labels.go", []string{tt.typ}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
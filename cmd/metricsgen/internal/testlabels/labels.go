@@ -0,0 +1,47 @@
+// Package testlabels defines label structs with generated methods, to test metricsgen
+// and to benchmark them against label structs parsed by reflection.
+package testlabels
+
+import "net/netip"
+
+//go:generate go run github.com/go-chi/metrics/cmd/metricsgen -type=RequestLabels,CommonLabels,BenchLabels
+
+// CommonLabels are labels shared by embedding.
+type CommonLabels struct {
+	Service string `label:"service"`
+}
+
+// Region is a named string type.
+type Region string
+
+// Status is a fmt.Stringer.
+type Status int
+
+func (s Status) String() string {
+	if s == 0 {
+		return "ok"
+	}
+	return "error"
+}
+
+// RequestLabels covers all supported label field types and tag options.
+type RequestLabels struct {
+	CommonLabels
+	Method   string     `label:"method,values=GET|POST,fallback=unknown"`
+	Endpoint string     `label:"endpoint,sanitize,maxlen=32"`
+	Code     int        `label:"code"`
+	Size     uint16     `label:"size"`
+	Cached   bool       `label:"cached"`
+	Region   Region     `label:"region,default=global"`
+	Status   Status     `label:"status"`
+	Addr     netip.Addr `label:"addr"`
+	Debug    string     `label:"-"`
+}
+
+// BenchLabels are typical labels of HTTP request metrics.
+type BenchLabels struct {
+	Method   string `label:"method"`
+	Endpoint string `label:"endpoint"`
+	Status   int    `label:"status"`
+	Cached   bool   `label:"cached"`
+}
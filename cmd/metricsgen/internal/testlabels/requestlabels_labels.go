@@ -0,0 +1,129 @@
+// Code generated by "metricsgen -type=RequestLabels,CommonLabels,BenchLabels"; DO NOT EDIT.
+
+package testlabels
+
+import (
+	"net/netip"
+	"strconv"
+
+	"github.com/go-chi/metrics"
+)
+
+var _ metrics.GeneratedLabels[RequestLabels] = RequestLabels{}
+
+// The methods of RequestLabels are out of date if it doesn't convert to the struct
+// they were generated for, or its `label` tags changed. Run go generate again.
+func _(l RequestLabels) {
+	_ = struct {
+		CommonLabels
+		Method   string
+		Endpoint string
+		Code     int
+		Size     uint16
+		Cached   bool
+		Region   Region
+		Status   Status
+		Addr     netip.Addr
+		Debug    string
+	}(l)
+}
+
+// LabelStruct returns the label struct itself.
+func (l RequestLabels) LabelStruct() RequestLabels {
+	return l
+}
+
+// LabelTags returns the `label` struct tags of RequestLabels.
+func (RequestLabels) LabelTags() []string {
+	return []string{
+		"service",
+		"method,values=GET|POST,fallback=unknown",
+		"endpoint,sanitize,maxlen=32",
+		"code",
+		"size",
+		"cached",
+		"region,default=global",
+		"status",
+		"addr",
+	}
+}
+
+// AppendLabelValues appends the label values of RequestLabels to dst, in the order of LabelTags.
+func (l RequestLabels) AppendLabelValues(dst []string) []string {
+	return append(dst,
+		l.CommonLabels.Service,
+		l.Method,
+		l.Endpoint,
+		metrics.LabelInt(int64(l.Code)),
+		metrics.LabelUint(uint64(l.Size)),
+		strconv.FormatBool(l.Cached),
+		string(l.Region),
+		l.Status.String(),
+		metrics.LabelText(l.Addr),
+	)
+}
+
+var _ metrics.GeneratedLabels[CommonLabels] = CommonLabels{}
+
+// The methods of CommonLabels are out of date if it doesn't convert to the struct
+// they were generated for, or its `label` tags changed. Run go generate again.
+func _(l CommonLabels) {
+	_ = struct{ Service string }(l)
+}
+
+// LabelStruct returns the label struct itself.
+func (l CommonLabels) LabelStruct() CommonLabels {
+	return l
+}
+
+// LabelTags returns the `label` struct tags of CommonLabels.
+func (CommonLabels) LabelTags() []string {
+	return []string{
+		"service",
+	}
+}
+
+// AppendLabelValues appends the label values of CommonLabels to dst, in the order of LabelTags.
+func (l CommonLabels) AppendLabelValues(dst []string) []string {
+	return append(dst,
+		l.Service,
+	)
+}
+
+var _ metrics.GeneratedLabels[BenchLabels] = BenchLabels{}
+
+// The methods of BenchLabels are out of date if it doesn't convert to the struct
+// they were generated for, or its `label` tags changed. Run go generate again.
+func _(l BenchLabels) {
+	_ = struct {
+		Method   string
+		Endpoint string
+		Status   int
+		Cached   bool
+	}(l)
+}
+
+// LabelStruct returns the label struct itself.
+func (l BenchLabels) LabelStruct() BenchLabels {
+	return l
+}
+
+// LabelTags returns the `label` struct tags of BenchLabels.
+func (BenchLabels) LabelTags() []string {
+	return []string{
+		"method",
+		"endpoint",
+		"status",
+		"cached",
+	}
+}
+
+// AppendLabelValues appends the label values of BenchLabels to dst, in the order of LabelTags.
+func (l BenchLabels) AppendLabelValues(dst []string) []string {
+	return append(dst,
+		l.Method,
+		l.Endpoint,
+		metrics.LabelInt(int64(l.Status)),
+		strconv.FormatBool(l.Cached),
+	)
+}
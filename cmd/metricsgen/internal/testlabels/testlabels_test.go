@@ -0,0 +1,182 @@
+package testlabels
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/metrics"
+	"github.com/go-chi/metrics/metricstest"
+)
+
+// reflectLabels has the fields of RequestLabels, but no generated methods.
+type reflectLabels struct {
+	CommonLabels
+	Method   string     `label:"method,values=GET|POST,fallback=unknown"`
+	Endpoint string     `label:"endpoint,sanitize,maxlen=32"`
+	Code     int        `label:"code"`
+	Size     uint16     `label:"size"`
+	Cached   bool       `label:"cached"`
+	Region   Region     `label:"region,default=global"`
+	Status   Status     `label:"status"`
+	Addr     netip.Addr `label:"addr"`
+	Debug    string     `label:"-"`
+}
+
+var requestLabels = RequestLabels{
+	CommonLabels: CommonLabels{Service: "api"},
+	Method:       "GET",
+	Endpoint:     "/users/{id}",
+	Code:         200,
+	Size:         512,
+	Cached:       true,
+	Status:       1,
+	Addr:         netip.MustParseAddr("10.0.0.1"),
+}
+
+func TestGeneratedLabels(t *testing.T) {
+	for _, labels := range []RequestLabels{
+		requestLabels,
+		{},
+		{Method: "DELETE", Endpoint: "/very/long/endpoint/\x00/with/control/characters", Code: -1, Region: "eu"},
+	} {
+		reg := metricstest.NewRegistry()
+		generated := metrics.CounterWith[RequestLabels]("requests_total", "Requests.", metrics.WithRegistry(reg))
+		reflected := metrics.CounterWith[reflectLabels]("reflected_requests_total", "Requests.", metrics.WithRegistry(reg))
+		generated.Inc(labels)
+		reflected.Inc(reflectLabels(labels))
+
+		got, want := series(t, reg, "requests_total"), series(t, reg, "reflected_requests_total")
+		if len(got) != 1 || !reflect.DeepEqual(got, want) {
+			t.Errorf("expected generated label values to match reflection:\ngot:  %v\nwant: %v", got, want)
+		}
+	}
+}
+
+// series returns the labels and values of the series of the named metric in the exposition.
+func series(t *testing.T, reg *metrics.Registry, name string) []string {
+	t.Helper()
+
+	var lines []string
+	for _, line := range strings.Split(metricstest.Exposition(t, reg), "\n") {
+		if labels, ok := strings.CutPrefix(line, name+"{"); ok {
+			lines = append(lines, labels)
+		}
+	}
+	return lines
+}
+
+func TestGeneratedMetrics(t *testing.T) {
+	reg := metricstest.NewRegistry()
+	generated := metrics.CounterWith[RequestLabels]("requests_total", "Requests.", metrics.WithRegistry(reg))
+	reflected := metrics.CounterWith[reflectLabels]("reflected_requests_total", "Requests.", metrics.WithRegistry(reg))
+
+	generated.Inc(requestLabels)
+	generated.Inc(RequestLabels{Method: "DELETE"}) // Replaced by the fallback value.
+	reflected.Inc(reflectLabels(requestLabels))
+	reflected.Inc(reflectLabels{Method: "DELETE"})
+
+	if got := metricstest.CounterValue(t, &generated, requestLabels); got != 1 {
+		t.Errorf("expected 1, got %v", got)
+	}
+	if got := generated.Value(RequestLabels{Method: "unknown"}); got != 1 {
+		t.Errorf("expected 1 for the fallback value, got %v", got)
+	}
+	if n := generated.DeletePartialMatch(RequestLabels{CommonLabels: CommonLabels{Service: "api"}}); n != 1 {
+		t.Errorf("expected 1 deleted series, got %d", n)
+	}
+	reflected.DeletePartialMatch(reflectLabels{CommonLabels: CommonLabels{Service: "api"}})
+
+	if got, want := series(t, reg, "requests_total"), series(t, reg, "reflected_requests_total"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected generated series to match reflection:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+// outerLabels embeds a label struct with generated methods, but has no generated methods itself.
+type outerLabels struct {
+	CommonLabels
+	Job string `label:"job"`
+}
+
+func TestPromotedMethods(t *testing.T) {
+	reg := metricstest.NewRegistry()
+	c := metrics.CounterWith[outerLabels]("jobs_total", "Jobs.", metrics.WithRegistry(reg))
+	c.Inc(outerLabels{CommonLabels: CommonLabels{Service: "api"}, Job: "sync"})
+
+	if got, want := series(t, reg, "jobs_total"), []string{`job="sync",service="api"} 1`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected series of the outer struct %v, got %v", want, got)
+	}
+}
+
+// reflectBenchLabels has the fields of BenchLabels, but no generated methods.
+type reflectBenchLabels struct {
+	Method   string `label:"method"`
+	Endpoint string `label:"endpoint"`
+	Status   int    `label:"status"`
+	Cached   bool   `label:"cached"`
+}
+
+var benchLabels = BenchLabels{Method: "GET", Endpoint: "/api/users/{id}", Status: 200, Cached: true}
+
+func BenchmarkCounterWithInc(b *testing.B) {
+	b.Run("generated", func(b *testing.B) {
+		c := metrics.CounterWith[BenchLabels]("bench_total", "Benchmark.", metrics.WithRegistry(metrics.NewRegistry()))
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.Inc(benchLabels)
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		c := metrics.CounterWith[reflectBenchLabels]("bench_total", "Benchmark.", metrics.WithRegistry(metrics.NewRegistry()))
+		labels := reflectBenchLabels(benchLabels)
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.Inc(labels)
+		}
+	})
+}
+
+func BenchmarkGaugeWithSet(b *testing.B) {
+	b.Run("generated", func(b *testing.B) {
+		g := metrics.GaugeWith[BenchLabels]("bench_gauge", "Benchmark.", metrics.WithRegistry(metrics.NewRegistry()))
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.Set(float64(i), benchLabels)
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		g := metrics.GaugeWith[reflectBenchLabels]("bench_gauge", "Benchmark.", metrics.WithRegistry(metrics.NewRegistry()))
+		labels := reflectBenchLabels(benchLabels)
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.Set(float64(i), labels)
+		}
+	})
+}
+
+func BenchmarkHistogramWithObserve(b *testing.B) {
+	buckets := []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	b.Run("generated", func(b *testing.B) {
+		h := metrics.HistogramWith[BenchLabels]("bench_seconds", "Benchmark.", buckets, metrics.WithRegistry(metrics.NewRegistry()))
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			h.Observe(0.042, benchLabels)
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		h := metrics.HistogramWith[reflectBenchLabels]("bench_seconds", "Benchmark.", buckets, metrics.WithRegistry(metrics.NewRegistry()))
+		labels := reflectBenchLabels(benchLabels)
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			h.Observe(0.042, labels)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Command metricsgen generates methods that describe label structs of github.com/go-chi/metrics
+// without reflection, see metrics.GeneratedLabels. Add a go:generate directive next to the label
+// structs and run go generate:
+//
+//	//go:generate go run github.com/go-chi/metrics/cmd/metricsgen -type=jobLabels,requestLabels
+//
+// The methods are written to <type>_labels.go, named after the first type, unless set by -output.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("metricsgen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of label struct type names; required")
+	output := flag.String("output", "", "output file name; default <dir>/<type>_labels.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: metricsgen -type=T [-output=file] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, strings.ToLower(types[0])+"_labels.go")
+	}
+
+	src, err := generate(dir, filepath.Base(outputName), types, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		log.Fatalf("invalid generated code: %v\n%s", err, src)
+	}
+	if err := os.WriteFile(outputName, formatted, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,299 @@
+// Package analyzer implements the metricslint analyzer, which reports mistakes in the label structs
+// and metric definitions of github.com/go-chi/metrics that would otherwise panic or fail at runtime:
+//   - label structs with missing or invalid `label` tags, unexported or unsupported fields,
+//     or duplicate label names
+//   - invalid metric names, and metric names defined more than once in the default registry,
+//     within a package or across the packages it imports
+//   - metrics created by panicking constructors, e.g. CounterWith, inside functions, which panic
+//     when the function is called again because the metric is already registered
+//
+// Metrics created with the WithRegistry or WithReuse options are not checked for duplicate names
+// or package-level definition, since they're usually created per registry on purpose. WithRegistry(nil)
+// and WithRegistry(metrics.DefaultRegistry) create metrics in the default registry, so they're checked.
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// metricsPath is the import path of the checked package.
+const metricsPath = "github.com/go-chi/metrics"
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "metricslint",
+	Doc:       "check label structs and metric definitions of github.com/go-chi/metrics",
+	URL:       "https://pkg.go.dev/github.com/go-chi/metrics/cmd/metricslint/analyzer",
+	Run:       run,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(definedMetrics)},
+}
+
+// nameValidation is the -names flag, see metrics.SetNameValidation.
+var nameValidation = "default"
+
+func init() {
+	Analyzer.Flags.StringVar(&nameValidation, "names", nameValidation, "name validation scheme set by metrics.SetNameValidation: default, lowercase or utf8")
+}
+
+// constructor describes a constructor of the metrics package.
+type constructor struct {
+	// named reports whether the first argument is the metric name.
+	named bool
+
+	// packageLevel reports whether the constructor panics if the metric is already registered,
+	// and thus must be called at package level.
+	packageLevel bool
+}
+
+var constructors = map[string]constructor{
+	"Counter":          {named: true, packageLevel: true},
+	"NewCounter":       {named: true},
+	"CounterWith":      {named: true, packageLevel: true},
+	"NewCounterWith":   {named: true},
+	"Gauge":            {named: true, packageLevel: true},
+	"NewGauge":         {named: true},
+	"GaugeWith":        {named: true, packageLevel: true},
+	"NewGaugeWith":     {named: true},
+	"Histogram":        {named: true, packageLevel: true},
+	"NewHistogram":     {named: true},
+	"HistogramWith":    {named: true, packageLevel: true},
+	"NewHistogramWith": {named: true},
+	"Summary":          {named: true, packageLevel: true},
+	"NewSummary":       {named: true},
+	"SummaryWith":      {named: true, packageLevel: true},
+	"NewSummaryWith":   {named: true},
+	"InfoWith":         {named: true, packageLevel: true},
+	"NewInfoWith":      {named: true},
+	"StateSetWith":     {named: true, packageLevel: true},
+	"NewStateSetWith":  {named: true},
+
+	// Scrape-time metrics are usually defined in main, next to the resources they observe.
+	"GaugeFunc":        {named: true},
+	"NewGaugeFunc":     {named: true},
+	"CounterFunc":      {named: true},
+	"NewCounterFunc":   {named: true},
+	"GaugeFuncWith":    {named: true},
+	"NewGaugeFuncWith": {named: true},
+	"BuildInfoWith":    {},
+	"NewBuildInfoWith": {},
+}
+
+// definedMetrics is a package fact listing the metric names the package defines in the default registry.
+type definedMetrics struct {
+	// Names maps metric names to the position of their definition.
+	Names map[string]string
+}
+
+func (*definedMetrics) AFact() {}
+
+func (f *definedMetrics) String() string {
+	names := make([]string, 0, len(f.Names))
+	for name := range f.Names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("metrics%v", names)
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	names, err := newNameValidator(nameValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Metric names defined by imported packages, see definedMetrics.
+	imported := map[string]string{}
+	for _, f := range pass.AllPackageFacts() {
+		if fact, ok := f.Fact.(*definedMetrics); ok && f.Package != pass.Pkg {
+			for name, pos := range fact.Names {
+				imported[name] = pos
+			}
+		}
+	}
+
+	c := &checker{
+		pass:     pass,
+		names:    names,
+		checked:  map[string]bool{},
+		defined:  map[string]string{},
+		imported: imported,
+	}
+
+	inspect.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if push {
+			c.checkCall(n.(*ast.CallExpr), enclosingFunc(stack))
+		}
+		return true
+	})
+
+	if len(c.defined) > 0 {
+		pass.ExportPackageFact(&definedMetrics{Names: c.defined})
+	}
+	return nil, nil
+}
+
+type checker struct {
+	pass  *analysis.Pass
+	names *nameValidator
+
+	// checked holds the label struct types that were already checked.
+	checked map[string]bool
+
+	// defined and imported map metric names defined in the default registry
+	// by this package and by imported packages to the position of their definition.
+	defined  map[string]string
+	imported map[string]string
+}
+
+// checkCall checks a call of a constructor of the metrics package. The enclosing function
+// declaration is nil for calls at package level.
+func (c *checker) checkCall(call *ast.CallExpr, fn *ast.FuncDecl) {
+	id := calleeIdent(call.Fun)
+	if id == nil {
+		return
+	}
+	callee, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || callee.Pkg() == nil || callee.Pkg().Path() != metricsPath {
+		return
+	}
+	ctor, ok := constructors[callee.Name()]
+	if !ok {
+		return
+	}
+
+	// The first type argument of generic constructors is the label struct.
+	if inst, ok := c.pass.TypesInfo.Instances[id]; ok && inst.TypeArgs.Len() > 0 {
+		c.checkLabels(call, inst.TypeArgs.At(0))
+	}
+
+	if !ctor.named || len(call.Args) == 0 {
+		return
+	}
+	registry, reuse := c.options(call)
+
+	tv := c.pass.TypesInfo.Types[call.Args[0]]
+	if tv.Value != nil && tv.Value.Kind() == constant.String {
+		name := constant.StringVal(tv.Value)
+		if !c.names.validMetricName(name) {
+			c.pass.Reportf(call.Args[0].Pos(), "invalid metric name %q: %s", name, c.names.metricNameRule())
+		} else if !registry && !reuse {
+			c.define(call, name)
+		}
+	}
+
+	if ctor.packageLevel && fn != nil && fn.Name.Name != "init" && !registry && !reuse {
+		c.pass.Reportf(call.Pos(), "metrics.%s inside function %s panics when called again, since the metric is already registered: "+
+			"define the metric at package level, or use the WithRegistry or WithReuse options", callee.Name(), fn.Name.Name)
+	}
+}
+
+// define records the metric name defined in the default registry, and reports it if it's already defined.
+func (c *checker) define(call *ast.CallExpr, name string) {
+	if pos, ok := c.defined[name]; ok {
+		c.pass.Reportf(call.Args[0].Pos(), "metric %q is already defined at %s", name, pos)
+		return
+	}
+	if pos, ok := c.imported[name]; ok {
+		c.pass.Reportf(call.Args[0].Pos(), "metric %q is already defined by an imported package at %s", name, pos)
+		return
+	}
+	c.defined[name] = c.pass.Fset.Position(call.Args[0].Pos()).String()
+}
+
+// options reports whether the call passes the WithRegistry option with a registry other than the default
+// registry, or the WithReuse option. Options that aren't passed as direct calls of option functions,
+// e.g. opts... or a variable, are unknown, so they're treated as if both were passed.
+func (c *checker) options(call *ast.CallExpr) (registry, reuse bool) {
+	if call.Ellipsis != token.NoPos {
+		return true, true
+	}
+	for _, arg := range call.Args {
+		if !isOption(c.pass.TypesInfo.TypeOf(arg)) {
+			continue
+		}
+		opt, ok := ast.Unparen(arg).(*ast.CallExpr)
+		if !ok {
+			return true, true
+		}
+		fn, ok := typeutil.Callee(c.pass.TypesInfo, opt).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != metricsPath {
+			return true, true
+		}
+		switch fn.Name() {
+		case "WithRegistry":
+			if len(opt.Args) != 1 || !c.isDefaultRegistry(opt.Args[0]) {
+				registry = true
+			}
+		case "WithReuse":
+			reuse = true
+		}
+	}
+	return registry, reuse
+}
+
+// isDefaultRegistry reports whether the registry argument of WithRegistry is the default registry,
+// i.e. nil or metrics.DefaultRegistry.
+func (c *checker) isDefaultRegistry(arg ast.Expr) bool {
+	if c.pass.TypesInfo.Types[arg].IsNil() {
+		return true
+	}
+	var id *ast.Ident
+	switch x := ast.Unparen(arg).(type) {
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	default:
+		return false
+	}
+	v, ok := c.pass.TypesInfo.Uses[id].(*types.Var)
+	return ok && v.Pkg() != nil && v.Pkg().Path() == metricsPath && v.Name() == "DefaultRegistry"
+}
+
+// isOption reports whether the type is metrics.Option.
+func isOption(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == metricsPath && obj.Name() == "Option"
+}
+
+// calleeIdent returns the identifier of the called function, e.g. CounterWith in metrics.CounterWith[T](...).
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch f := ast.Unparen(fun).(type) {
+	case *ast.IndexExpr:
+		return calleeIdent(f.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(f.X)
+	case *ast.SelectorExpr:
+		return f.Sel
+	case *ast.Ident:
+		return f
+	}
+	return nil
+}
+
+// enclosingFunc returns the innermost function declaration of the stack, or nil at package level.
+// Function literals at package level, e.g. in var initializers, run once like package-level code.
+func enclosingFunc(stack []ast.Node) *ast.FuncDecl {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if fn, ok := stack[i].(*ast.FuncDecl); ok {
+			return fn
+		}
+	}
+	return nil
+}
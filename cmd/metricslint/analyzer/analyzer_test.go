@@ -0,0 +1,19 @@
+package analyzer
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestLabels(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "labels")
+}
+
+func TestNames(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "names")
+}
+
+func TestPackageLevel(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "funcs")
+}
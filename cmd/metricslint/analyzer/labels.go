@@ -0,0 +1,186 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	stringerType = newInterface("String", types.Typ[types.String])
+
+	textMarshalerType = newInterface("MarshalText", types.NewSlice(types.Typ[types.Byte]), types.Universe.Lookup("error").Type())
+)
+
+// newInterface returns an interface with a single method without parameters.
+func newInterface(method string, results ...types.Type) *types.Interface {
+	vars := make([]*types.Var, len(results))
+	for i, typ := range results {
+		vars[i] = types.NewParam(token.NoPos, nil, "", typ)
+	}
+	sig := types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(vars...), false)
+	return types.NewInterfaceType([]*types.Func{types.NewFunc(token.NoPos, nil, method, sig)}, nil).Complete()
+}
+
+// checkLabels checks the label struct type of a constructor call, like the metrics package does
+// when the metric is created. Each label struct type is checked once per package.
+func (c *checker) checkLabels(call *ast.CallExpr, t types.Type) {
+	if _, ok := t.(*types.TypeParam); ok {
+		// Label structs of generic functions are checked where they're instantiated.
+		return
+	}
+
+	key := types.TypeString(t, nil)
+	if c.checked[key] {
+		return
+	}
+	c.checked[key] = true
+
+	structType := t
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		structType = ptr.Elem()
+	}
+	st, ok := structType.Underlying().(*types.Struct)
+	if !ok {
+		c.pass.Reportf(call.Pos(), "invalid label type %s (must be struct)", types.TypeString(t, types.RelativeTo(c.pass.Pkg)))
+		return
+	}
+
+	c.checkFields(call, structType, st, map[string]string{})
+}
+
+// checkFields checks the fields of a label struct, flattening embedded structs without a `label` tag.
+// The seen map tracks label names to the fields defining them.
+func (c *checker) checkFields(call *ast.CallExpr, structType types.Type, st *types.Struct, seen map[string]string) {
+	structName := types.TypeString(structType, types.RelativeTo(c.pass.Pkg))
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		report := func(format string, args ...any) {
+			c.reportField(call, field, fmt.Sprintf("field %s of label struct %s: ", field.Name(), structName)+format, args...)
+		}
+
+		labelTag, hasTag := reflect.StructTag(st.Tag(i)).Lookup("label")
+		if labelTag == "-" {
+			continue
+		}
+		if labelTag == "" && field.Embedded() {
+			if embedded, ok := field.Type().Underlying().(*types.Struct); ok {
+				if !field.Exported() {
+					report("embedded label structs must be exported")
+					continue
+				}
+				c.checkFields(call, field.Type(), embedded, seen)
+				continue
+			}
+			if ptr, ok := field.Type().Underlying().(*types.Pointer); ok {
+				if _, ok := ptr.Elem().Underlying().(*types.Struct); ok {
+					report("embedded label structs must not be pointers, embed %s instead", types.TypeString(ptr.Elem(), types.RelativeTo(c.pass.Pkg)))
+					continue
+				}
+			}
+		}
+
+		if labelTag == "" {
+			if hasTag {
+				report("empty `label` struct tag")
+			} else {
+				report("missing `label` struct tag")
+			}
+			continue
+		}
+		name, err := parseLabelTag(labelTag)
+		if err != nil {
+			report("invalid `label` struct tag %q: %v", labelTag, err)
+			continue
+		}
+		if !c.names.validLabelName(name) {
+			report("invalid label name %q: %s", name, c.names.labelNameRule())
+			continue
+		}
+		if !field.Exported() {
+			report("label struct fields must be exported")
+			continue
+		}
+		if !validLabelType(field.Type()) {
+			report("unsupported label type %s: must be string, int, uint, bool, fmt.Stringer or encoding.TextMarshaler", types.TypeString(field.Type(), types.RelativeTo(c.pass.Pkg)))
+			continue
+		}
+
+		if other, ok := seen[name]; ok {
+			report("duplicate label name %q, already defined by %s", name, other)
+			continue
+		}
+		seen[name] = structName + "." + field.Name()
+	}
+}
+
+// reportField reports a problem of a label struct field at the field, if it's defined
+// in the analyzed package, or otherwise at the constructor call.
+func (c *checker) reportField(call *ast.CallExpr, field *types.Var, format string, args ...any) {
+	pos := call.Pos()
+	if field.Pkg() == c.pass.Pkg && field.Pos().IsValid() {
+		pos = field.Pos()
+	}
+	c.pass.Reportf(pos, format, args...)
+}
+
+// validLabelType reports whether values of the type can be formatted as label values,
+// like the labelKindOf function of the metrics package.
+func validLabelType(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Interface, *types.Pointer:
+		// Nil values can't be formatted.
+		return false
+	}
+	if types.Implements(t, textMarshalerType) || types.Implements(t, stringerType) {
+		return true
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsString|types.IsInteger|types.IsBoolean) != 0
+}
+
+// parseLabelTag parses a `label` struct tag like the parseLabelTag function of the metrics package,
+// and returns the label name.
+func parseLabelTag(s string) (string, error) {
+	name, opts, _ := strings.Cut(s, ",")
+	var values, fallback bool
+
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "values":
+			if value == "" {
+				return "", errors.New("values option must list allowed values, e.g. values=a|b|c")
+			}
+			values = true
+		case "fallback":
+			fallback = value != ""
+		case "default":
+			if value == "" {
+				return "", errors.New("default option must set a value, e.g. default=unknown")
+			}
+		case "maxlen":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return "", errors.New("maxlen option must be a positive number, e.g. maxlen=64")
+			}
+		case "sanitize":
+		default:
+			return "", fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	if fallback && !values {
+		return "", errors.New("fallback option requires the values option")
+	}
+	return name, nil
+}
@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// nameValidator validates metric and label names like the name validation schemes of the metrics package.
+type nameValidator struct {
+	scheme string
+}
+
+func newNameValidator(scheme string) (*nameValidator, error) {
+	switch scheme {
+	case "default", "lowercase", "utf8":
+		return &nameValidator{scheme: scheme}, nil
+	}
+	return nil, fmt.Errorf("invalid -names flag %q: must be default, lowercase or utf8", scheme)
+}
+
+// validMetricName checks if a metric name is valid, see metrics.DefaultNameValidation.
+func (v *nameValidator) validMetricName(s string) bool {
+	switch v.scheme {
+	case "lowercase":
+		return isLowercaseName(s)
+	case "utf8":
+		return s != "" && utf8.ValidString(s)
+	}
+
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && c != ':' && !isASCIILetter(c) && (i == 0 || !isASCIIDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// validLabelName checks if a label name is valid and isn't reserved by Prometheus.
+func (v *nameValidator) validLabelName(s string) bool {
+	if strings.HasPrefix(s, "__") {
+		return false
+	}
+
+	switch v.scheme {
+	case "lowercase":
+		return isLowercaseName(s)
+	case "utf8":
+		return s != "" && utf8.ValidString(s)
+	}
+
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && !isASCIILetter(c) && (i == 0 || !isASCIIDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// metricNameRule describes valid metric names for diagnostics.
+func (v *nameValidator) metricNameRule() string {
+	switch v.scheme {
+	case "lowercase":
+		return "must match [a-z_][a-z0-9_]*"
+	case "utf8":
+		return "must be non-empty valid UTF-8"
+	}
+	return "must match [a-zA-Z_:][a-zA-Z0-9_:]*"
+}
+
+// labelNameRule describes valid label names for diagnostics.
+func (v *nameValidator) labelNameRule() string {
+	switch v.scheme {
+	case "lowercase":
+		return "must match [a-z_][a-z0-9_]* and not start with __"
+	case "utf8":
+		return "must be non-empty valid UTF-8 and not start with __"
+	}
+	return "must match [a-zA-Z_][a-zA-Z0-9_]* and not start with __"
+}
+
+// isLowercaseName checks if a name matches [a-z_][a-z0-9_]*.
+func isLowercaseName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && (c < 'a' || c > 'z') && (i == 0 || !isASCIIDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,33 @@
+package funcs // want package:`metrics\[attempts_total handled_total handling init_total jobs_total queue_length started_total\]`
+
+import "github.com/go-chi/metrics"
+
+type jobLabels struct {
+	Name string `label:"name"`
+}
+
+var jobs = func() metrics.CounterMetricLabeled[jobLabels] {
+	return metrics.CounterWith[jobLabels]("jobs_total", "Jobs.") // Runs once at package initialization.
+}()
+
+func init() {
+	metrics.Counter("init_total", "Init.")
+}
+
+func handle() {
+	c := metrics.CounterWith[jobLabels]("handled_total", "Handled.") // want `metrics.CounterWith inside function handle panics when called again, since the metric is already registered: define the metric at package level, or use the WithRegistry or WithReuse options`
+	_ = c
+
+	_ = metrics.GaugeWith[jobLabels]("handling", "Handling.", metrics.WithRegistry(&metrics.Registry{}))
+	_ = metrics.CounterWith[jobLabels]("handled_total", "Handled.", metrics.WithReuse())
+	_ = metrics.GaugeWith[jobLabels]("handling", "Handling.", metrics.WithRegistry(nil)) // want `metrics.GaugeWith inside function handle panics`
+	_, _ = metrics.NewCounter("attempts_total", "Attempts.")
+	metrics.GaugeFunc("queue_length", "Queue length.", func() float64 { return 0 })
+	metrics.BuildInfo()
+}
+
+type server struct{}
+
+func (s *server) start() {
+	_ = metrics.Counter("started_total", "Started.") // want `metrics.Counter inside function start panics`
+}
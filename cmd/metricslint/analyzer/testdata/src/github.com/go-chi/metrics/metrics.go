@@ -0,0 +1,41 @@
+// Package metrics is a stub of the constructors and options of github.com/go-chi/metrics.
+package metrics
+
+type Registry struct{}
+
+var DefaultRegistry = &Registry{}
+
+func NewRegistry() *Registry { return &Registry{} }
+
+type Option func()
+
+func WithRegistry(r *Registry) Option { return nil }
+func WithReuse() Option               { return nil }
+
+type CounterMetric struct{}
+type CounterMetricLabeled[T any] struct{}
+type GaugeMetricLabeled[T any] struct{}
+type HistogramMetricLabeled[T any] struct{}
+type InfoMetric[T any] struct{}
+
+func Counter(name, help string, opts ...Option) CounterMetric { return CounterMetric{} }
+func NewCounter(name, help string, opts ...Option) (CounterMetric, error) {
+	return CounterMetric{}, nil
+}
+func CounterWith[T any](name, help string, opts ...Option) CounterMetricLabeled[T] {
+	return CounterMetricLabeled[T]{}
+}
+func GaugeWith[T any](name, help string, opts ...Option) GaugeMetricLabeled[T] {
+	return GaugeMetricLabeled[T]{}
+}
+func NewGaugeWith[T any](name, help string, opts ...Option) (GaugeMetricLabeled[T], error) {
+	return GaugeMetricLabeled[T]{}, nil
+}
+func HistogramWith[T any](name, help string, buckets []float64, opts ...Option) HistogramMetricLabeled[T] {
+	return HistogramMetricLabeled[T]{}
+}
+func InfoWith[T any](name, help string, labels T, opts ...Option) InfoMetric[T] {
+	return InfoMetric[T]{}
+}
+func GaugeFunc(name, help string, fn func() float64, opts ...Option) {}
+func BuildInfo(opts ...Option)                                       {}
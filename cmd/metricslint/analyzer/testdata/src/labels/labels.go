@@ -0,0 +1,65 @@
+package labels // want package:`metrics\[embedded inferred_info invalid invalid_again not_struct_total valid_total\]`
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/go-chi/metrics"
+)
+
+type Status int
+
+func (s Status) String() string { return "status" }
+
+type validLabels struct {
+	Name     string        `label:"name,sanitize,maxlen=64"`
+	Timeout  time.Duration `label:"timeout"` // fmt.Stringer
+	Code     int           `label:"code"`
+	Cached   bool          `label:"cached"`
+	Status   Status        `label:"status,values=ok|error,fallback=unknown"`
+	Addr     netip.Addr    `label:"addr"`
+	Region   string        `label:"region,default=unknown"`
+	Internal string        `label:"-"`
+	Common
+}
+
+type Common struct {
+	Service string `label:"service"`
+}
+
+var _ = metrics.CounterWith[validLabels]("valid_total", "Valid.")
+
+type invalidLabels struct {
+	Missing    string  // want `field Missing of label struct invalidLabels: missing .label. struct tag`
+	Empty      string  `label:""`                    // want `field Empty of label struct invalidLabels: empty .label. struct tag`
+	BadName    string  `label:"bad-name"`            // want `field BadName of label struct invalidLabels: invalid label name "bad-name": must match`
+	Reserved   string  `label:"__name"`              // want `invalid label name "__name"`
+	BadOption  string  `label:"opt,values="`         // want `invalid .label. struct tag "opt,values=": values option must list allowed values`
+	Unknown    string  `label:"unknown,foo"`         // want `unknown option "foo"`
+	Fallback   string  `label:"fallback,fallback=x"` // want `fallback option requires the values option`
+	unexported string  `label:"unexported"`          // want `label struct fields must be exported`
+	Float      float64 `label:"float"`               // want `unsupported label type float64: must be string, int, uint, bool, fmt.Stringer or encoding.TextMarshaler`
+	Pointer    *string `label:"pointer"`             // want `unsupported label type \*string`
+	Any        any     `label:"any"`                 // want `unsupported label type any`
+	Name       string  `label:"name"`
+	Duplicate  string  `label:"name"` // want `duplicate label name "name", already defined by invalidLabels.Name`
+	*Common            // want `embedded label structs must not be pointers, embed Common instead`
+}
+
+var _ = metrics.GaugeWith[invalidLabels]("invalid", "Invalid.")
+
+// Label structs are checked once.
+var _, _ = metrics.NewGaugeWith[invalidLabels]("invalid_again", "Invalid.")
+
+type embedded struct {
+	Common
+	Service string `label:"service"` // want `duplicate label name "service", already defined by Common.Service`
+}
+
+var _ = metrics.HistogramWith[embedded]("embedded", "Embedded.", nil)
+
+var _ = metrics.CounterWith[string]("not_struct_total", "Not a struct.") // want `invalid label type string \(must be struct\)`
+
+var _ = metrics.InfoWith("inferred_info", "Inferred.", struct {
+	Version string // want `field Version of label struct struct{Version string}: missing .label. struct tag`
+}{})
@@ -0,0 +1,5 @@
+package defs // want package:`metrics\[jobs_total\]`
+
+import "github.com/go-chi/metrics"
+
+var Jobs = metrics.Counter("jobs_total", "Jobs.")
@@ -0,0 +1,35 @@
+package names // want package:`metrics\[job:duration:rate5m requests_total\]`
+
+import (
+	"github.com/go-chi/metrics"
+
+	"names/defs"
+)
+
+var _ = defs.Jobs
+
+var (
+	_ = metrics.Counter("requests_total", "Requests.")
+	_ = metrics.Counter("requests_total", "Requests.") // want `metric "requests_total" is already defined at .*names.go:12:22`
+	_ = metrics.Counter("jobs_total", "Jobs.")         // want `metric "jobs_total" is already defined by an imported package at .*defs.go:5:28`
+	_ = metrics.Counter("job:duration:rate5m", "Recording rule.")
+	_ = metrics.Counter("1jobs_total", "Jobs.")                                               // want `invalid metric name "1jobs_total": must match \[a-zA-Z_:\]\[a-zA-Z0-9_:\]\*`
+	_ = metrics.Counter("jobs-total", "Jobs.")                                                // want `invalid metric name "jobs-total"`
+	_ = metrics.Counter("", "Jobs.")                                                          // want `invalid metric name ""`
+	_ = metrics.Counter(prefix+"-total", "Jobs.")                                             // want `invalid metric name "jobs-total"`
+	_ = metrics.Counter(name(), "Jobs.")                                                      // Names that aren't constant are not checked.
+	_ = metrics.Counter("jobs_total", "Jobs.", reg)                                           // Other registries may define the same names.
+	_ = metrics.Counter("jobs_total", "Jobs.", metrics.WithRegistry(nil))                     // want `metric "jobs_total" is already defined by an imported package`
+	_ = metrics.Counter("jobs_total", "Jobs.", metrics.WithRegistry(metrics.DefaultRegistry)) // want `metric "jobs_total" is already defined by an imported package`
+	_ = metrics.Counter("jobs_total", "Jobs.", metrics.WithReuse())
+	_ = metrics.Counter("jobs_total", "Jobs.", opts...)
+)
+
+const prefix = "jobs"
+
+var (
+	reg  = metrics.WithRegistry(metrics.NewRegistry())
+	opts []metrics.Option
+)
+
+func name() string { return "jobs_total" }
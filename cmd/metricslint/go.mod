@@ -0,0 +1,11 @@
+module github.com/go-chi/metrics/cmd/metricslint
+
+go 1.22.0
+
+require golang.org/x/tools v0.30.0
+
+require (
+	github.com/google/go-cmp v0.7.0 // indirect
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
@@ -0,0 +1,16 @@
+// Command metricslint checks the label structs and metric definitions of github.com/go-chi/metrics,
+// which are otherwise only validated at runtime, when the metrics are created:
+//
+//	go run github.com/go-chi/metrics/cmd/metricslint@latest ./...
+//
+// See the analyzer package for the checks.
+package main
+
+import (
+	"github.com/go-chi/metrics/cmd/metricslint/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
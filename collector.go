@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -14,15 +13,40 @@ import (
 )
 
 var (
-	requestsCounter   = CounterWith[requestLabels]("http_requests_total", "Total number of incoming HTTP requests.")
-	inflightGauge     = GaugeWith[inflightLabels]("http_requests_inflight", "Number of incoming HTTP requests currently in flight.")
-	requestsHistogram = HistogramWith[histogramLabels](
-		"http_request_duration_seconds",
-		"Response latency in seconds for completed incoming HTTP requests.",
-		[]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 25, 50, 100},
-	)
+	// durationBuckets are the classic histogram buckets of the built-in HTTP latency metrics.
+	durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 25, 50, 100}
+
+	// durationNativeHistogram enables native histograms for the built-in HTTP latency metrics,
+	// so they can be scraped with a precision that doesn't depend on durationBuckets.
+	durationNativeHistogram = NativeHistogramOpts{
+		BucketFactor:     1.1,
+		MaxBucketNumber:  100,
+		MinResetDuration: time.Hour,
+	}
 )
 
+// serverMetrics holds the built-in metrics for incoming HTTP requests of a single registry.
+type serverMetrics struct {
+	requestsCounter   CounterMetricLabeled[requestLabels]
+	inflightGauge     GaugeMetricLabeled[inflightLabels]
+	requestsHistogram HistogramMetricLabeled[histogramLabels]
+}
+
+func newServerMetrics(reg *Registry) *serverMetrics {
+	return &serverMetrics{
+		requestsCounter: CounterWith[requestLabels]("http_requests_total", "Total number of incoming HTTP requests.", WithRegistry(reg)),
+		inflightGauge:   GaugeWith[inflightLabels]("http_requests_inflight", "Number of incoming HTTP requests currently in flight.", WithRegistry(reg)),
+		requestsHistogram: HistogramWith[histogramLabels](
+			"http_request_duration_seconds",
+			"Response latency in seconds for completed incoming HTTP requests.",
+			durationBuckets,
+			WithRegistry(reg),
+			WithNativeHistogram(durationNativeHistogram),
+			WithUnit("seconds"),
+		),
+	}
+}
+
 // CollectorOpts configures the HTTP request metrics collector.
 type CollectorOpts struct {
 	// Host enables tracking of request "host" label.
@@ -34,12 +58,20 @@ type CollectorOpts struct {
 	// Skip is an optional predicate function that determines whether to skip recording metrics for a given request.
 	// If nil, all requests are recorded. If provided, requests where Skip returns true will not be recorded.
 	Skip func(r *http.Request) bool
+
+	// TraceID optionally extracts the trace ID of a request from its context, e.g. from an OpenTelemetry span.
+	// The trace ID is attached as a trace_id exemplar to the metrics of the request. If nil, empty or invalid,
+	// the trace ID set by ContextWithTraceID or the sampled trace of the W3C traceparent request header is used.
+	TraceID func(ctx context.Context) string
+
+	// Registry is the registry to record metrics into. If nil, DefaultRegistry is used.
+	Registry *Registry
 }
 
 // requestLabels defines labels for the counter of total incoming HTTP requests.
 type requestLabels struct {
-	Host          string `label:"host"`
-	Status        string `label:"status"`
+	Host          string `label:"host,sanitize,maxlen=255"`
+	Status        int    `label:"status"`
 	Endpoint      string `label:"endpoint"`
 	Proto         string `label:"proto"`
 	ClientAborted string `label:"client_aborted"`
@@ -47,13 +79,13 @@ type requestLabels struct {
 
 // histogramLabels defines labels for the histogram of completed incoming HTTP requests.
 type histogramLabels struct {
-	Status   string `label:"status"`
+	Status   int    `label:"status"`
 	Endpoint string `label:"endpoint"`
 }
 
 // inflightLabels defines labels for the gauge of in-flight incoming HTTP requests.
 type inflightLabels struct {
-	Host  string `label:"host"`
+	Host  string `label:"host,sanitize,maxlen=255"`
 	Proto string `label:"proto"`
 }
 
@@ -62,7 +94,12 @@ type inflightLabels struct {
 // - http_requests_total: Total number of incoming HTTP requests
 // - http_requests_inflight: Number of incoming HTTP requests currently in flight
 // - http_request_duration_seconds: Response latency in seconds for completed requests
+//
+// The trace ID of the request, see CollectorOpts.TraceID, is attached as an exemplar
+// to http_requests_total and http_request_duration_seconds.
 func Collector(opts CollectorOpts) func(next http.Handler) http.Handler {
+	m := opts.Registry.orDefault().httpServer()
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if opts.Skip != nil && opts.Skip(r) {
@@ -76,7 +113,7 @@ func Collector(opts CollectorOpts) func(next http.Handler) http.Handler {
 				Host:  getHost(r, opts.Host),
 				Proto: getProto(r, opts.Proto),
 			}
-			inflightGauge.Inc(inflightLabels)
+			m.inflightGauge.Inc(inflightLabels)
 
 			ww, ok := w.(middleware.WrapResponseWriter)
 			if !ok {
@@ -85,7 +122,7 @@ func Collector(opts CollectorOpts) func(next http.Handler) http.Handler {
 
 			defer func() {
 				duration := time.Since(start).Seconds()
-				inflightGauge.Dec(inflightLabels)
+				m.inflightGauge.Dec(inflightLabels)
 
 				endpoint := "<no-match>"
 				if rctx := chi.RouteContext(r.Context()); rctx != nil {
@@ -103,23 +140,26 @@ func Collector(opts CollectorOpts) func(next http.Handler) http.Handler {
 
 				labels := requestLabels{
 					Host:     inflightLabels.Host,
-					Status:   strconv.Itoa(statusCode),
+					Status:   statusCode,
 					Endpoint: endpoint,
 					Proto:    inflightLabels.Proto,
 				}
 
+				// Link the metrics to the trace of the request, if any.
+				exemplar := traceExemplar(r.Context(), r.Header, opts.TraceID)
+
 				if errors.Is(r.Context().Err(), context.Canceled) {
 					labels.ClientAborted = "true"
 				} else {
 					// Observe duration of completed requests.
-					requestsHistogram.Observe(duration, histogramLabels{
+					m.requestsHistogram.ObserveWithExemplar(duration, histogramLabels{
 						Status:   labels.Status,
 						Endpoint: labels.Endpoint,
-					})
+					}, exemplar)
 				}
 
 				// Track total number of requests.
-				requestsCounter.Inc(labels)
+				m.requestsCounter.IncWithExemplar(labels, exemplar)
 			}()
 
 			next.ServeHTTP(ww, r)
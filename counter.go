@@ -1,47 +1,172 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
 
 // Counter creates a counter metric
-func Counter(name string, help string) CounterMetric {
-	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: mustValidMetricName(name),
-		Help: help,
-	}, []string{})
-	prometheus.MustRegister(vec)
-	return CounterMetric{vec: vec}
+func Counter(name string, help string, opts ...Option) CounterMetric {
+	c, err := NewCounter(name, help, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewCounter creates a counter metric, or returns an error if the metric is invalid
+// or can't be registered
+func NewCounter(name string, help string, opts ...Option) (CounterMetric, error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	if err := o.validate(dto.MetricType_COUNTER, name, nil); err != nil {
+		return CounterMetric{}, err
+	}
+	vec, err := register(o, name, prometheus.NewCounterVec(o.counterOpts(name, help), []string{}))
+	if err != nil {
+		return CounterMetric{}, err
+	}
+	return CounterMetric{counter: vec.WithLabelValues()}, nil
 }
 
 // CounterWith creates a counter metric with typed labels
-func CounterWith[T any](name string, help string) CounterMetricLabeled[T] {
-	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: mustValidMetricName(name),
-		Help: help,
-	}, getLabelKeys[T]())
-	prometheus.MustRegister(vec)
-	return CounterMetricLabeled[T]{vec: vec}
+func CounterWith[T any](name string, help string, opts ...Option) CounterMetricLabeled[T] {
+	c, err := NewCounterWith[T](name, help, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewCounterWith creates a counter metric with typed labels, or returns an error if the metric
+// or its labels are invalid or it can't be registered
+func NewCounterWith[T any](name string, help string, opts ...Option) (CounterMetricLabeled[T], error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return CounterMetricLabeled[T]{}, err
+	}
+	if err := o.validate(dto.MetricType_COUNTER, name, ls.keys); err != nil {
+		return CounterMetricLabeled[T]{}, err
+	}
+	vec, err := register(o, name, prometheus.NewCounterVec(o.counterOpts(name, help), ls.keys))
+	if err != nil {
+		return CounterMetricLabeled[T]{}, err
+	}
+	return CounterMetricLabeled[T]{
+		vec: vec,
+		labeled: newLabeledVec(o, name, ls, vec.MetricVec, vec.WithLabelValues, func() prometheus.Counter {
+			return prometheus.NewCounter(o.counterOpts(name, help))
+		}),
+	}, nil
+}
+
+func (o *metricOpts) counterOpts(name, help string) prometheus.CounterOpts {
+	return prometheus.CounterOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: o.constLabels,
+	}
 }
 
 type CounterMetric struct {
-	vec *prometheus.CounterVec
+	counter prometheus.Counter
+
+	// bound looks up the series again on every use of handles of metrics with a TTL, see withBoundLabelValues.
+	bound func() prometheus.Counter
+}
+
+// metric returns the series of the handle.
+func (c *CounterMetric) metric() prometheus.Counter {
+	if c.bound != nil {
+		return c.bound()
+	}
+	return c.counter
 }
 
 func (c *CounterMetric) Inc() {
-	c.vec.With(prometheus.Labels{}).Inc()
+	c.metric().Inc()
 }
 
 func (c *CounterMetric) Add(value float64) {
-	c.vec.With(prometheus.Labels{}).Add(value)
+	c.metric().Add(value)
+}
+
+// IncWithExemplar increments the counter and attaches an exemplar, e.g. {"trace_id": "..."},
+// served in the OpenMetrics exposition format. If exemplar is nil, no exemplar is attached.
+// It panics if the exemplar labels are invalid or longer than 128 runes in total.
+func (c *CounterMetric) IncWithExemplar(exemplar map[string]string) {
+	addWithExemplar(c.metric(), 1, exemplar)
+}
+
+// AddWithExemplar adds the value to the counter and attaches an exemplar, see IncWithExemplar.
+func (c *CounterMetric) AddWithExemplar(value float64, exemplar map[string]string) {
+	addWithExemplar(c.metric(), value, exemplar)
+}
+
+// Value returns the current value of the counter.
+func (c *CounterMetric) Value() float64 {
+	return readMetric(c.metric()).GetCounter().GetValue()
 }
 
 type CounterMetricLabeled[T any] struct {
-	vec *prometheus.CounterVec
+	vec     *prometheus.CounterVec
+	labeled *labeledVec[prometheus.Counter]
 }
 
 func (c *CounterMetricLabeled[T]) Inc(labels T) {
-	c.vec.With(getLabelValues(labels)).Inc()
+	withLabelValues(c.labeled, labels).Inc()
 }
 
 func (c *CounterMetricLabeled[T]) Add(value float64, labels T) {
-	c.vec.With(getLabelValues(labels)).Add(value)
+	withLabelValues(c.labeled, labels).Add(value)
+}
+
+// IncWithExemplar increments the counter and attaches an exemplar, see CounterMetric.IncWithExemplar.
+func (c *CounterMetricLabeled[T]) IncWithExemplar(labels T, exemplar map[string]string) {
+	addWithExemplar(withLabelValues(c.labeled, labels), 1, exemplar)
+}
+
+// AddWithExemplar adds the value to the counter and attaches an exemplar, see CounterMetric.IncWithExemplar.
+func (c *CounterMetricLabeled[T]) AddWithExemplar(value float64, labels T, exemplar map[string]string) {
+	addWithExemplar(withLabelValues(c.labeled, labels), value, exemplar)
+}
+
+// Value returns the current value of the counter with the given labels, or 0 if it doesn't exist.
+// It doesn't create the series, but looks it up among all series of the counter; to read a value
+// repeatedly, e.g. in a load shedder, bind the labels with With and call Value on the returned counter.
+func (c *CounterMetricLabeled[T]) Value(labels T) float64 {
+	return lookupLabelValues(c.labeled, labels).GetCounter().GetValue()
+}
+
+// With returns the counter for the given labels. The returned counter can be cached
+// and used in hot paths to skip the label lookup on every call.
+func (c *CounterMetricLabeled[T]) With(labels T) CounterMetric {
+	counter, bound := withBoundLabelValues(c.labeled, labels)
+	return CounterMetric{counter: counter, bound: bound}
+}
+
+// Init creates the series for all combinations of the allowed values of label fields restricted
+// by the `values` tag option, so that they are exported with zero values before the first observation.
+// Values of the other label fields are taken from labels.
+func (c *CounterMetricLabeled[T]) Init(labels T) {
+	initLabelValues(c.labeled, labels)
+}
+
+// Delete deletes the series with the given labels, e.g. of a worker that disappeared,
+// and reports whether it existed.
+func (c *CounterMetricLabeled[T]) Delete(labels T) bool {
+	return deleteLabelValues(c.labeled, labels)
+}
+
+// DeletePartialMatch deletes all series matching the non-zero fields of labels, e.g. all series
+// of a tenant, and returns the number of deleted series. If all fields are zero, all series are deleted.
+func (c *CounterMetricLabeled[T]) DeletePartialMatch(labels T) int {
+	return deletePartialMatch(c.labeled, labels)
+}
+
+// Reset deletes all series.
+func (c *CounterMetricLabeled[T]) Reset() {
+	resetLabelValues(c.labeled)
 }
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoundMetrics(t *testing.T) {
+	type jobLabels struct {
+		Name   string `label:"name"`
+		Status int    `label:"status"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+	g := GaugeWith[*jobLabels]("jobs_running", "Running jobs.", WithRegistry(reg))
+	h := HistogramWith[jobLabels]("job_duration_seconds", "Job duration.", []float64{1, 10}, WithRegistry(reg))
+
+	labels := jobLabels{Name: "a", Status: 200}
+
+	bc := c.With(labels)
+	bc.Inc()
+	bc.Add(2)
+	c.Inc(labels) // Unbound and bound calls update the same series.
+
+	bg := g.With(&labels)
+	bg.Set(10)
+	bg.Dec()
+
+	bh := h.With(labels)
+	bh.Observe(5)
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`jobs_total{name="a",status="200"} 4`,
+		`jobs_running{name="a",status="200"} 9`,
+		`job_duration_seconds_bucket{name="a",status="200",le="10"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TraceIDExemplarLabel is the exemplar label holding the trace ID, which Grafana uses
+// to link from metrics to traces.
+const TraceIDExemplarLabel = "trace_id"
+
+type traceIDKey struct{}
+
+// ContextWithTraceID returns a copy of ctx carrying the trace ID. Collector and Transport attach it
+// as a trace_id exemplar to the metrics of the request, unless it's invalid UTF-8 or too long for
+// an exemplar. For incoming requests, set it in a tracing middleware that runs before Collector.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID set by ContextWithTraceID, or an empty string.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// traceExemplar returns the trace_id exemplar for the trace ID of a request, or nil if there is none.
+// The trace ID is taken from the extract function if set, from ContextWithTraceID, or from the
+// traceparent header, whichever is the first valid one.
+func traceExemplar(ctx context.Context, header http.Header, extract func(ctx context.Context) string) map[string]string {
+	if extract != nil {
+		if traceID := extract(ctx); validTraceID(traceID) {
+			return map[string]string{TraceIDExemplarLabel: traceID}
+		}
+	}
+	if traceID := TraceIDFromContext(ctx); validTraceID(traceID) {
+		return map[string]string{TraceIDExemplarLabel: traceID}
+	}
+	if traceID, ok := parseTraceparent(header.Get(TraceparentHeader)); ok && validTraceID(traceID) {
+		return map[string]string{TraceIDExemplarLabel: traceID}
+	}
+	return nil
+}
+
+// validTraceID reports whether the trace ID fits into a trace_id exemplar. Prometheus panics on
+// exemplars that aren't valid UTF-8 or exceed ExemplarMaxRunes, including the label name.
+func validTraceID(traceID string) bool {
+	return traceID != "" && utf8.ValidString(traceID) &&
+		utf8.RuneCountInString(TraceIDExemplarLabel)+utf8.RuneCountInString(traceID) <= prometheus.ExemplarMaxRunes
+}
+
+func addWithExemplar(c prometheus.Counter, value float64, exemplar map[string]string) {
+	if exemplar == nil {
+		c.Add(value)
+		return
+	}
+	c.(prometheus.ExemplarAdder).AddWithExemplar(value, exemplar)
+}
+
+func observeWithExemplar(o prometheus.Observer, value float64, exemplar map[string]string) {
+	if exemplar == nil {
+		o.Observe(value)
+		return
+	}
+	o.(prometheus.ExemplarObserver).ObserveWithExemplar(value, exemplar)
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExemplars(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+
+	reg := NewRegistry()
+	counter := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+	histogram := HistogramWith[jobLabels]("job_duration_seconds", "Job duration.", []float64{1, 10}, WithRegistry(reg), WithUnit("seconds"))
+
+	counter.IncWithExemplar(jobLabels{Name: "a"}, map[string]string{"trace_id": "abc"})
+	histogram.ObserveWithExemplar(5, jobLabels{Name: "a"}, map[string]string{"trace_id": "def"})
+
+	// Nil exemplars are ignored.
+	bound := counter.With(jobLabels{Name: "b"})
+	bound.AddWithExemplar(2, nil)
+
+	body := scrapeOpenMetrics(t, reg.Handler())
+	for _, want := range []string{
+		`jobs_total{name="a"} 1.0 # {trace_id="abc"} 1.0`,
+		`jobs_total{name="b"} 2.0` + "\n",
+		`job_duration_seconds_bucket{name="a",le="10.0"} 1 # {trace_id="def"} 5.0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+
+	// The Prometheus text format doesn't support exemplars.
+	if body := scrape(t, reg.Handler()); strings.Contains(body, "trace_id") {
+		t.Errorf("unexpected exemplar in text format:\n%s", body)
+	}
+}
+
+func TestCollectorExemplars(t *testing.T) {
+	reg := NewRegistry()
+
+	tracing := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(ContextWithTraceID(r.Context(), "4bf92f3577b34da6a3ce929d0e0e4736")))
+		})
+	}
+	backend := httptest.NewServer(tracing(Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			// Propagate the trace to outgoing requests.
+			req, _ := http.NewRequestWithContext(r.Context(), "GET", "http://"+r.Host+"/ping", nil)
+			client := &http.Client{Transport: Transport(TransportOpts{Registry: reg})(http.DefaultTransport)}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}
+	}))))
+	defer backend.Close()
+
+	resp, err := http.Get(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	body := scrapeOpenMetrics(t, reg.Handler())
+	for _, metric := range []string{"http_requests_total{", "http_request_duration_seconds_bucket{", "http_client_requests_total{", "http_client_request_duration_seconds_bucket{"} {
+		if !hasExemplar(body, metric, "4bf92f3577b34da6a3ce929d0e0e4736") {
+			t.Errorf("expected exemplar on %s in output:\n%s", metric, body)
+		}
+	}
+}
+
+func TestCollectorInvalidTraceID(t *testing.T) {
+	reg := NewRegistry()
+
+	for _, traceID := range []string{strings.Repeat("a", 200), "\xff\xfe"} {
+		handler := Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ContextWithTraceID(req.Context(), traceID)))
+	}
+
+	// The requests are recorded without exemplars.
+	body := scrapeOpenMetrics(t, reg.Handler())
+	if !strings.Contains(body, `http_requests_total{`) || !strings.Contains(body, `} 2.0`) {
+		t.Errorf("expected 2 requests in output:\n%s", body)
+	}
+	if strings.Contains(body, "trace_id") {
+		t.Errorf("unexpected exemplar in output:\n%s", body)
+	}
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// GaugeFunc creates a gauge metric whose value is provided by fn at scrape time, e.g. the size
+// of a pool or cache. fn must be safe for concurrent use.
+func GaugeFunc(name, help string, fn func() float64, opts ...Option) {
+	if err := NewGaugeFunc(name, help, fn, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// NewGaugeFunc creates a gauge metric whose value is provided by fn at scrape time,
+// or returns an error if the metric is invalid or can't be registered
+func NewGaugeFunc(name, help string, fn func() float64, opts ...Option) error {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	if err := o.validate(dto.MetricType_GAUGE, name, nil); err != nil {
+		return err
+	}
+	_, err := register(o, name, prometheus.NewGaugeFunc(o.gaugeOpts(name, help), fn))
+	return err
+}
+
+// CounterFunc creates a counter metric whose value is provided by fn at scrape time, e.g. a counter
+// maintained by another library. The value must never decrease. fn must be safe for concurrent use.
+func CounterFunc(name, help string, fn func() float64, opts ...Option) {
+	if err := NewCounterFunc(name, help, fn, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// NewCounterFunc creates a counter metric whose value is provided by fn at scrape time,
+// or returns an error if the metric is invalid or can't be registered
+func NewCounterFunc(name, help string, fn func() float64, opts ...Option) error {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	if err := o.validate(dto.MetricType_COUNTER, name, nil); err != nil {
+		return err
+	}
+	_, err := register(o, name, prometheus.NewCounterFunc(o.counterOpts(name, help), fn))
+	return err
+}
+
+// LabeledValue is the value of a series with typed labels, returned by the callback of GaugeFuncWith.
+type LabeledValue[T any] struct {
+	Labels T
+	Value  float64
+}
+
+// GaugeFuncWith creates a gauge metric with typed labels whose series are provided by fn at scrape time,
+// e.g. the depth of each queue:
+//
+//	metrics.GaugeFuncWith[queueLabels]("queue_depth", "Number of queued jobs", func() []metrics.LabeledValue[queueLabels] {
+//		return []metrics.LabeledValue[queueLabels]{
+//			{Labels: queueLabels{Queue: "default"}, Value: float64(defaultQueue.Len())},
+//			{Labels: queueLabels{Queue: "priority"}, Value: float64(priorityQueue.Len())},
+//		}
+//	})
+//
+// Values of series with the same label values, e.g. after replacing values not allowed by
+// the `values` tag option, are summed. fn must be safe for concurrent use.
+func GaugeFuncWith[T any](name, help string, fn func() []LabeledValue[T], opts ...Option) {
+	if err := NewGaugeFuncWith(name, help, fn, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// NewGaugeFuncWith creates a gauge metric with typed labels whose series are provided by fn at scrape time,
+// or returns an error if the metric or its labels are invalid or it can't be registered
+func NewGaugeFuncWith[T any](name, help string, fn func() []LabeledValue[T], opts ...Option) error {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return err
+	}
+	if err := o.validate(dto.MetricType_GAUGE, name, ls.keys); err != nil {
+		return err
+	}
+	_, err = register(o, name, &funcCollector[T]{
+		desc:      prometheus.NewDesc(name, help, ls.keys, o.constLabels),
+		valueType: prometheus.GaugeValue,
+		labels:    ls,
+		reject:    o.labelValuePolicy == LabelValuesReject,
+		fn:        fn,
+	})
+	return err
+}
+
+// funcCollector collects the series of a metric with typed labels provided by a callback at scrape time.
+type funcCollector[T any] struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	labels    *labelInfo
+	reject    bool
+	fn        func() []LabeledValue[T]
+}
+
+func (c *funcCollector[T]) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *funcCollector[T]) Collect(ch chan<- prometheus.Metric) {
+	var (
+		series []LabeledValue[[]string]
+		index  = map[string]int{}
+	)
+
+	for _, lv := range c.fn() {
+		values := labelValues(c.labels, nil, &lv.Labels)
+		if !c.labels.allow(values, c.reject) {
+			continue
+		}
+
+		key := strings.Join(values, "\xff")
+		if i, ok := index[key]; ok {
+			series[i].Value += lv.Value
+			continue
+		}
+		index[key] = len(series)
+		series = append(series, LabeledValue[[]string]{Labels: values, Value: lv.Value})
+	}
+
+	for _, s := range series {
+		m, err := prometheus.NewConstMetric(c.desc, c.valueType, s.Value, s.Labels...)
+		if err != nil {
+			m = prometheus.NewInvalidMetric(c.desc, err)
+		}
+		ch <- m
+	}
+}
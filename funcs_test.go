@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFuncMetrics(t *testing.T) {
+	type queueLabels struct {
+		Queue    string `label:"queue,values=default|priority"`
+		Priority int    `label:"priority"`
+	}
+
+	reg := NewRegistry()
+
+	var pool atomic.Int64
+	GaugeFunc("pool_size", "Pool size.", func() float64 { return float64(pool.Load()) }, WithRegistry(reg))
+	CounterFunc("cache_hits_total", "Cache hits.", func() float64 { return 42 }, WithRegistry(reg))
+	GaugeFuncWith("queue_depth", "Queue depth.", func() []LabeledValue[queueLabels] {
+		return []LabeledValue[queueLabels]{
+			{Labels: queueLabels{Queue: "default", Priority: 1}, Value: 3},
+			{Labels: queueLabels{Queue: "priority", Priority: 2}, Value: 1},
+			{Labels: queueLabels{Queue: "batch", Priority: 1}, Value: 5},
+			{Labels: queueLabels{Queue: "bulk", Priority: 1}, Value: 2},
+		}
+	}, WithRegistry(reg), WithConstLabels(map[string]string{"shard": "a"}))
+
+	pool.Store(7)
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		"pool_size 7",
+		"cache_hits_total 42",
+		`queue_depth{priority="1",queue="default",shard="a"} 3`,
+		`queue_depth{priority="2",queue="priority",shard="a"} 1`,
+		`queue_depth{priority="1",queue="other",shard="a"} 7`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+
+	// Series are provided at scrape time.
+	pool.Store(8)
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, "pool_size 8") {
+		t.Errorf("expected updated pool_size in output:\n%s", body)
+	}
+
+	if err := NewGaugeFunc("pool_size", "Pool size.", func() float64 { return 0 }, WithRegistry(reg)); err == nil {
+		t.Error("expected error for duplicate metric")
+	}
+}
+
+func TestGaugeFuncWithReject(t *testing.T) {
+	type queueLabels struct {
+		Queue string `label:"queue,values=default"`
+	}
+
+	reg := NewRegistry()
+	GaugeFuncWith("queue_depth", "Queue depth.", func() []LabeledValue[queueLabels] {
+		return []LabeledValue[queueLabels]{
+			{Labels: queueLabels{Queue: "default"}, Value: 3},
+			{Labels: queueLabels{Queue: "batch"}, Value: 5},
+		}
+	}, WithRegistry(reg), WithLabelValuePolicy(LabelValuesReject))
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, `queue_depth{queue="default"} 3`) || strings.Contains(body, "batch") || strings.Contains(body, "other") {
+		t.Errorf("expected only the allowed series in output:\n%s", body)
+	}
+}
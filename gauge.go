@@ -1,63 +1,165 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
 
 // Gauge creates a gauge metric
-func Gauge(name, help string) GaugeMetric {
-	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: mustValidMetricName(name),
-		Help: help,
-	}, []string{})
-	prometheus.MustRegister(vec)
-	return GaugeMetric{vec: vec}
+func Gauge(name, help string, opts ...Option) GaugeMetric {
+	g, err := NewGauge(name, help, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// NewGauge creates a gauge metric, or returns an error if the metric is invalid
+// or can't be registered
+func NewGauge(name, help string, opts ...Option) (GaugeMetric, error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	if err := o.validate(dto.MetricType_GAUGE, name, nil); err != nil {
+		return GaugeMetric{}, err
+	}
+	vec, err := register(o, name, prometheus.NewGaugeVec(o.gaugeOpts(name, help), []string{}))
+	if err != nil {
+		return GaugeMetric{}, err
+	}
+	return GaugeMetric{gauge: vec.WithLabelValues()}, nil
 }
 
 // GaugeWith creates a gauge metric with typed labels
-func GaugeWith[T any](name, help string) GaugeMetricLabeled[T] {
-	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: mustValidMetricName(name),
-		Help: help,
-	}, getLabelKeys[T]())
-	prometheus.MustRegister(vec)
-	return GaugeMetricLabeled[T]{vec: vec}
+func GaugeWith[T any](name, help string, opts ...Option) GaugeMetricLabeled[T] {
+	g, err := NewGaugeWith[T](name, help, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// NewGaugeWith creates a gauge metric with typed labels, or returns an error if the metric
+// or its labels are invalid or it can't be registered
+func NewGaugeWith[T any](name, help string, opts ...Option) (GaugeMetricLabeled[T], error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return GaugeMetricLabeled[T]{}, err
+	}
+	if err := o.validate(dto.MetricType_GAUGE, name, ls.keys); err != nil {
+		return GaugeMetricLabeled[T]{}, err
+	}
+	vec, err := register(o, name, prometheus.NewGaugeVec(o.gaugeOpts(name, help), ls.keys))
+	if err != nil {
+		return GaugeMetricLabeled[T]{}, err
+	}
+	return GaugeMetricLabeled[T]{
+		vec: vec,
+		labeled: newLabeledVec(o, name, ls, vec.MetricVec, vec.WithLabelValues, func() prometheus.Gauge {
+			return prometheus.NewGauge(o.gaugeOpts(name, help))
+		}),
+	}, nil
+}
+
+func (o *metricOpts) gaugeOpts(name, help string) prometheus.GaugeOpts {
+	return prometheus.GaugeOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: o.constLabels,
+	}
 }
 
 type GaugeMetric struct {
-	vec *prometheus.GaugeVec
+	gauge prometheus.Gauge
+
+	// bound looks up the series again on every use of handles of metrics with a TTL, see withBoundLabelValues.
+	bound func() prometheus.Gauge
+}
+
+// metric returns the series of the handle.
+func (g *GaugeMetric) metric() prometheus.Gauge {
+	if g.bound != nil {
+		return g.bound()
+	}
+	return g.gauge
 }
 
 func (g *GaugeMetric) Set(value float64) {
-	g.vec.With(prometheus.Labels{}).Set(value)
+	g.metric().Set(value)
 }
 
 func (g *GaugeMetric) Add(value float64) {
-	g.vec.With(prometheus.Labels{}).Add(value)
+	g.metric().Add(value)
 }
 
 func (g *GaugeMetric) Inc() {
-	g.vec.With(prometheus.Labels{}).Add(1.0)
+	g.metric().Add(1.0)
 }
 
 func (g *GaugeMetric) Dec() {
-	g.vec.With(prometheus.Labels{}).Add(-1.0)
+	g.metric().Add(-1.0)
+}
+
+// Value returns the current value of the gauge.
+func (g *GaugeMetric) Value() float64 {
+	return readMetric(g.metric()).GetGauge().GetValue()
 }
 
 type GaugeMetricLabeled[T any] struct {
-	vec *prometheus.GaugeVec
+	vec     *prometheus.GaugeVec
+	labeled *labeledVec[prometheus.Gauge]
 }
 
 func (g *GaugeMetricLabeled[T]) Set(value float64, labels T) {
-	g.vec.With(getLabelValues(labels)).Set(value)
+	withLabelValues(g.labeled, labels).Set(value)
 }
 
 func (g *GaugeMetricLabeled[T]) Add(value float64, labels T) {
-	g.vec.With(getLabelValues(labels)).Add(value)
+	withLabelValues(g.labeled, labels).Add(value)
 }
 
 func (g *GaugeMetricLabeled[T]) Inc(labels T) {
-	g.vec.With(getLabelValues(labels)).Add(1.0)
+	withLabelValues(g.labeled, labels).Add(1.0)
 }
 
 func (g *GaugeMetricLabeled[T]) Dec(labels T) {
-	g.vec.With(getLabelValues(labels)).Add(-1.0)
+	withLabelValues(g.labeled, labels).Add(-1.0)
+}
+
+// Value returns the current value of the gauge with the given labels, or 0 if it doesn't exist.
+// It doesn't create the series, see CounterMetricLabeled.Value.
+func (g *GaugeMetricLabeled[T]) Value(labels T) float64 {
+	return lookupLabelValues(g.labeled, labels).GetGauge().GetValue()
+}
+
+// With returns the gauge for the given labels. The returned gauge can be cached
+// and used in hot paths to skip the label lookup on every call.
+func (g *GaugeMetricLabeled[T]) With(labels T) GaugeMetric {
+	gauge, bound := withBoundLabelValues(g.labeled, labels)
+	return GaugeMetric{gauge: gauge, bound: bound}
+}
+
+// Init creates the series for all combinations of the allowed values of label fields restricted
+// by the `values` tag option, so that they are exported with zero values before the first observation.
+// Values of the other label fields are taken from labels.
+func (g *GaugeMetricLabeled[T]) Init(labels T) {
+	initLabelValues(g.labeled, labels)
+}
+
+// Delete deletes the series with the given labels, e.g. of a worker that disappeared,
+// and reports whether it existed.
+func (g *GaugeMetricLabeled[T]) Delete(labels T) bool {
+	return deleteLabelValues(g.labeled, labels)
+}
+
+// DeletePartialMatch deletes all series matching the non-zero fields of labels, e.g. all series
+// of a tenant, and returns the number of deleted series. If all fields are zero, all series are deleted.
+func (g *GaugeMetricLabeled[T]) DeletePartialMatch(labels T) int {
+	return deletePartialMatch(g.labeled, labels)
+}
+
+// Reset deletes all series.
+func (g *GaugeMetricLabeled[T]) Reset() {
+	resetLabelValues(g.labeled)
 }
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// GeneratedLabels is implemented by label structs of type T with methods generated by metricsgen,
+// which describe the labels without reflection:
+//
+//	//go:generate go run github.com/go-chi/metrics/cmd/metricsgen -type=jobLabels
+//
+// Constructors, e.g. CounterWith, detect these methods and use them instead of reading the label
+// values by reflection. Label structs must be passed by value, e.g. CounterWith[jobLabels].
+// Re-run go generate whenever the label struct changes: the generated code doesn't compile if
+// fields were renamed or their types changed, and constructors return an error if the `label`
+// tags changed.
+type GeneratedLabels[T any] interface {
+	// LabelStruct returns the label struct itself. It ensures that the methods belong to T,
+	// and aren't promoted from an embedded label struct with generated methods.
+	LabelStruct() T
+
+	// LabelTags returns the `label` struct tags of the label fields, e.g. "status,values=ok|error",
+	// in the order of the label values.
+	LabelTags() []string
+
+	// AppendLabelValues appends the formatted label values to dst, in the order of LabelTags.
+	// The `label` tag options, e.g. default and maxlen, are applied by the metrics afterwards.
+	AppendLabelValues(dst []string) []string
+}
+
+// newGeneratedLabelInfo describes a label struct by its generated methods.
+func newGeneratedLabelInfo[T any](g GeneratedLabels[T]) (*labelInfo, error) {
+	var zero T
+	tags := g.LabelTags()
+	ls := &labelInfo{generated: true}
+	ls.scratch.New = func() any { return new(labelScratch[T]) }
+
+	seen := map[string]bool{}
+	for _, labelTag := range tags {
+		tag, err := parseLabelTag(labelTag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `label` struct tag %q of %T: %v", labelTag, zero, err)
+		}
+		if !isValidLabelName(tag.name) {
+			return nil, fmt.Errorf("invalid `label` name %q of %T: label %s", tag.name, zero, loadNameValidation().labelNameRule())
+		}
+		if seen[tag.name] {
+			return nil, fmt.Errorf("duplicate `label` name %q of %T", tag.name, zero)
+		}
+		seen[tag.name] = true
+
+		ls.keys = append(ls.keys, tag.name)
+		ls.fields = append(ls.fields, labelField{
+			allowed:      tag.values,
+			fallback:     tag.fallback,
+			defaultValue: tag.defaultValue,
+			maxLen:       tag.maxLen,
+			sanitize:     tag.sanitize,
+		})
+	}
+
+	// The generated methods must match the tags, or label values would be assigned to the wrong keys.
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Struct && !slices.Equal(structLabelTags(t, nil), tags) {
+		return nil, fmt.Errorf("generated label methods of %T are out of date: `label` struct tags changed (run go generate)", zero)
+	}
+	ls.zeroValues = g.AppendLabelValues(nil)
+	if len(ls.zeroValues) != len(tags) {
+		return nil, fmt.Errorf("generated label methods of %T are out of date: %d label values for %d label tags (run go generate)", zero, len(ls.zeroValues), len(tags))
+	}
+	for i := range ls.fields {
+		ls.zeroValues[i] = ls.fields[i].normalize(ls.zeroValues[i])
+	}
+
+	return ls, nil
+}
+
+// structLabelTags appends the `label` struct tags of the label fields of structType to tags,
+// flattening embedded structs and skipping fields like addFields.
+func structLabelTags(structType reflect.Type, tags []string) []string {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		labelTag := field.Tag.Get("label")
+		switch {
+		case labelTag == "-":
+		case labelTag == "" && field.Anonymous && field.Type.Kind() == reflect.Struct:
+			tags = structLabelTags(field.Type, tags)
+		default:
+			tags = append(tags, labelTag)
+		}
+	}
+	return tags
+}
+
+// LabelInt formats an integer label value without allocations for common values,
+// e.g. HTTP status codes. It's used by code generated by metricsgen.
+func LabelInt(i int64) string {
+	return formatInt(i)
+}
+
+// LabelUint formats an unsigned integer label value without allocations for common values.
+// It's used by code generated by metricsgen.
+func LabelUint(u uint64) string {
+	return formatUint(u)
+}
+
+// LabelText formats a label value by its MarshalText method, or returns an empty string if it fails.
+// It's used by code generated by metricsgen.
+func LabelText(m encoding.TextMarshaler) string {
+	text, err := m.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(text)
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+// handLabels implements GeneratedLabels by hand, like code generated by metricsgen.
+type handLabels struct {
+	Name   string `label:"name,default=unknown,maxlen=4"`
+	Status int    `label:"status"`
+	Extra  string `label:"-"`
+}
+
+func (l handLabels) LabelStruct() handLabels { return l }
+func (handLabels) LabelTags() []string       { return []string{"name,default=unknown,maxlen=4", "status"} }
+func (l handLabels) AppendLabelValues(dst []string) []string {
+	return append(dst, l.Name, LabelInt(int64(l.Status)))
+}
+
+// staleLabels has generated methods that don't match its label values.
+type staleLabels struct {
+	Name   string `label:"name"`
+	Status int    `label:"status"`
+}
+
+func (l staleLabels) LabelStruct() staleLabels              { return l }
+func (staleLabels) LabelTags() []string                     { return []string{"name", "status"} }
+func (staleLabels) AppendLabelValues(dst []string) []string { return append(dst, "name") }
+
+// renamedLabels has generated methods that don't match its label tags, which were changed afterwards.
+type renamedLabels struct {
+	Code int `label:"http_code"`
+}
+
+func (l renamedLabels) LabelStruct() renamedLabels { return l }
+func (renamedLabels) LabelTags() []string          { return []string{"code"} }
+func (l renamedLabels) AppendLabelValues(dst []string) []string {
+	return append(dst, LabelInt(int64(l.Code)))
+}
+
+// invalidLabels has generated methods with an invalid label tag.
+type invalidLabels struct{}
+
+func (l invalidLabels) LabelStruct() invalidLabels            { return l }
+func (invalidLabels) LabelTags() []string                     { return []string{"name", "name"} }
+func (invalidLabels) AppendLabelValues(dst []string) []string { return append(dst, "a", "b") }
+
+func TestGeneratedLabels(t *testing.T) {
+	ls, err := getLabelInfo[handLabels]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ls.generated {
+		t.Fatal("expected generated label methods to be used")
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[handLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+	c.Inc(handLabels{Status: 200})
+	c.Inc(handLabels{Name: "sync-job", Status: 500})
+	c.DeletePartialMatch(handLabels{Status: 500})
+
+	body := scrape(t, reg.Handler())
+	if want := `jobs_total{name="unknown",status="200"} 1`; !strings.Contains(body, want) {
+		t.Errorf("expected %q in output:\n%s", want, body)
+	}
+	if unwanted := `name="sync"`; strings.Contains(body, unwanted) {
+		t.Errorf("unexpected %q in output:\n%s", unwanted, body)
+	}
+
+	if _, err := NewCounterWith[staleLabels]("stale_total", "Stale.", WithRegistry(reg)); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Errorf("expected error for out of date methods, got %v", err)
+	}
+	if _, err := NewCounterWith[renamedLabels]("renamed_total", "Renamed.", WithRegistry(reg)); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Errorf("expected error for changed label tags, got %v", err)
+	}
+	if _, err := NewCounterWith[invalidLabels]("invalid_total", "Invalid.", WithRegistry(reg)); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected error for duplicate labels, got %v", err)
+	}
+}
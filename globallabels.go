@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// globalLabels holds the sorted label pairs set by SetGlobalLabels.
+var globalLabels atomic.Pointer[[]*dto.LabelPair]
+
+// SetGlobalLabels sets labels, e.g. app, version or region, that are added to every metric
+// served by the Handler of any registry, including the built-in Collector and Transport metrics.
+// Labels are added at scrape time, so SetGlobalLabels can be called after metrics were created,
+// e.g. at the start of main. Labels already defined by a metric take precedence.
+// It panics if any of the label names are invalid, or are "le" or "quantile", which are
+// reserved for histogram buckets and summary quantiles.
+func SetGlobalLabels(labels map[string]string) {
+	if err := validConstLabels(labels); err != nil {
+		panic(err)
+	}
+	for _, reserved := range []string{"le", "quantile"} {
+		if _, ok := labels[reserved]; ok {
+			panic(fmt.Errorf("global label %q is reserved for histogram buckets and summary quantiles", reserved))
+		}
+	}
+
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	slices.SortFunc(pairs, compareLabelPairs)
+
+	globalLabels.Store(&pairs)
+}
+
+// WithConstLabels adds constant labels to the metric, which have the same value for all its series.
+func WithConstLabels(labels map[string]string) Option {
+	return func(o *metricOpts) {
+		o.constLabels = labels
+	}
+}
+
+// validConstLabels checks the names of constant labels.
+func validConstLabels(labels map[string]string) error {
+	for name := range labels {
+		if !isValidLabelName(name) {
+			return fmt.Errorf("invalid constant label name: %s (%s)", name, loadNameValidation().labelNameRule())
+		}
+	}
+	return nil
+}
+
+// validConstLabelKeys checks the names of constant labels and that they don't clash with the label keys.
+func validConstLabelKeys(labels map[string]string, keys []string) error {
+	if err := validConstLabels(labels); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, ok := labels[key]; ok {
+			return fmt.Errorf("constant label %q is already defined by the label struct", key)
+		}
+	}
+	return nil
+}
+
+// addGlobalLabels adds the labels set by SetGlobalLabels to all gathered metrics.
+func addGlobalLabels(mfs []*dto.MetricFamily) {
+	labels := globalLabels.Load()
+	if labels == nil || len(*labels) == 0 {
+		return
+	}
+
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			m.Label = mergeLabelPairs(m.GetLabel(), *labels)
+		}
+	}
+}
+
+// mergeLabelPairs merges the sorted label pairs into a new sorted slice. On duplicate names,
+// the metric's label is kept. The metric's labels are never modified in place, since the
+// slice may be shared with the metric across scrapes.
+func mergeLabelPairs(metric, global []*dto.LabelPair) []*dto.LabelPair {
+	merged := make([]*dto.LabelPair, 0, len(metric)+len(global))
+	i, j := 0, 0
+	for i < len(metric) && j < len(global) {
+		switch c := compareLabelPairs(metric[i], global[j]); {
+		case c < 0:
+			merged = append(merged, metric[i])
+			i++
+		case c > 0:
+			merged = append(merged, global[j])
+			j++
+		default:
+			merged = append(merged, metric[i])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, metric[i:]...)
+	return append(merged, global[j:]...)
+}
+
+func compareLabelPairs(a, b *dto.LabelPair) int {
+	return strings.Compare(a.GetName(), b.GetName())
+}
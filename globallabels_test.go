@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConstLabels(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg), WithConstLabels(map[string]string{"queue": "default"}))
+	g := Gauge("workers", "Workers.", WithRegistry(reg), WithConstLabels(map[string]string{"pool": "main"}))
+
+	c.Inc(jobLabels{Name: "a"})
+	g.Set(3)
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`jobs_total{name="a",queue="default"} 1`,
+		`workers{pool="main"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+
+	if _, err := NewCounterWith[jobLabels]("clash_total", "Clash.", WithRegistry(reg), WithConstLabels(map[string]string{"name": "x"})); err == nil {
+		t.Error("expected error for constant label clashing with label struct")
+	}
+	if _, err := NewCounter("invalid_total", "Invalid.", WithRegistry(reg), WithConstLabels(map[string]string{"in-valid": "x"})); err == nil {
+		t.Error("expected error for invalid constant label name")
+	}
+	if _, err := NewHistogram("le_seconds", "Le.", nil, WithRegistry(reg), WithConstLabels(map[string]string{"le": "x"})); err == nil {
+		t.Error("expected error for reserved histogram label")
+	}
+}
+
+func TestGlobalLabels(t *testing.T) {
+	type jobLabels struct {
+		Region string `label:"region"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+	c.Inc(jobLabels{Region: "eu"})
+
+	// Global labels apply to metrics created before they were set, including built-in metrics.
+	SetGlobalLabels(map[string]string{"app": "api", "region": "global", "version": "v1.2.3"})
+	t.Cleanup(func() { SetGlobalLabels(nil) })
+
+	handler := Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	// Scrape twice to make sure global labels don't accumulate.
+	scrape(t, reg.Handler())
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`jobs_total{app="api",region="eu",version="v1.2.3"} 1`,
+		`http_requests_total{app="api",client_aborted="",endpoint="<no-match>",host="",proto="",region="global",status="200",version="v1.2.3"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
+
+func TestGlobalLabelsReserved(t *testing.T) {
+	for _, name := range []string{"le", "quantile"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for reserved global label %q", name)
+				}
+			}()
+			SetGlobalLabels(map[string]string{name: "x"})
+		}()
+	}
+	if labels := globalLabels.Load(); labels != nil && len(*labels) > 0 {
+		t.Errorf("unexpected global labels after panic: %v", *labels)
+	}
+}
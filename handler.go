@@ -1,10 +1,19 @@
 package metrics
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
-	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
 )
 
 // Handler returns an HTTP handler that serves Prometheus metrics from the default registry
@@ -13,5 +22,108 @@ import (
 // This handler should typically be mounted at "/metrics" and protected from public access,
 // e.g. via middleware.BasicAuth or exposed only on a private port.
 func Handler() http.Handler {
-	return promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{})
+	return DefaultRegistry.Handler()
+}
+
+// WithErrorLog sets the logger of errors that occur while the Handler of the registry writes
+// the response, e.g. when the scraper disconnects. Defaults to the standard logger.
+func WithErrorLog(logger promhttp.Logger) RegistryOption {
+	return func(r *Registry) {
+		r.errorLog = logger
+	}
+}
+
+// newHandler returns an HTTP handler serving the gathered metrics of the registry in the format
+// negotiated with the scraper. Unlike promhttp.HandlerFor, it serves OpenMetrics with # UNIT metadata
+// and the info and stateset types.
+func newHandler(reg *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mfs, err := reg.gather()
+		if err != nil {
+			http.Error(w, "An error has occurred while serving metrics:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+		w.Header().Set("Content-Type", string(format))
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		var out io.Writer = w
+		if acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+
+		openMetrics := format.FormatType() == expfmt.TypeOpenMetrics
+		enc := expfmt.NewEncoder(out, format, expfmt.WithUnit())
+		for _, mf := range mfs {
+			if typ := reg.openMetricsType(mf.GetName()); openMetrics && typ != "" {
+				err = encodeOpenMetricsType(out, model.EscapeMetricFamily(mf, format.ToEscapingScheme()), typ)
+			} else {
+				err = enc.Encode(mf)
+			}
+			if err != nil {
+				// The response has already started, so the error can't be reported to the scraper.
+				reg.logError("error encoding and sending metric family:", err)
+				return
+			}
+		}
+		if closer, ok := enc.(expfmt.Closer); ok {
+			if err := closer.Close(); err != nil {
+				reg.logError("error encoding and sending metrics:", err)
+			}
+		}
+	})
+}
+
+// logError logs an error of the handler to the logger set by WithErrorLog, or the standard logger.
+func (r *Registry) logError(v ...any) {
+	if r.errorLog != nil {
+		r.errorLog.Println(v...)
+		return
+	}
+	log.Println(v...)
+}
+
+// encodeOpenMetricsType encodes a gauge metric family in the OpenMetrics format with the given type,
+// which the Prometheus encoder doesn't support. The family name of info metrics omits the _info suffix.
+func encodeOpenMetricsType(w io.Writer, mf *dto.MetricFamily, typ string) error {
+	name := mf.GetName()
+	if typ == "info" {
+		name = strings.TrimSuffix(name, "_info")
+	}
+	if !model.IsValidLegacyMetricName(name) {
+		name = strconv.Quote(name)
+	}
+
+	var buf bytes.Buffer
+	if mf.Help != nil {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", name, helpEscaper.Replace(mf.GetHelp()))
+	}
+	fmt.Fprintf(&buf, "# TYPE %s %s\n", name, typ)
+
+	// Encode the samples as a gauge without metadata, and drop the gauge # TYPE line.
+	mf.Help = nil
+	var samples bytes.Buffer
+	if _, err := expfmt.MetricFamilyToOpenMetrics(&samples, mf); err != nil {
+		return err
+	}
+	_, sampleLines, _ := bytes.Cut(samples.Bytes(), []byte("\n"))
+	buf.Write(sampleLines)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if name, _, _ := strings.Cut(strings.TrimSpace(enc), ";"); name == "gzip" {
+			return true
+		}
+	}
+	return false
 }
@@ -1,42 +1,236 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 
-// Histogram creates a histogram metric
-func Histogram(name, help string, buckets []float64) HistogramMetric {
-	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    mustValidMetricName(name),
-		Help:    help,
-		Buckets: buckets,
-	}, []string{})
-	prometheus.MustRegister(vec)
-	return HistogramMetric{vec: vec}
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Histogram creates a histogram metric. Pass nil buckets together with the WithNativeHistogram option
+// to create a native histogram without classic buckets.
+func Histogram(name, help string, buckets []float64, opts ...Option) HistogramMetric {
+	h, err := NewHistogram(name, help, buckets, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// NewHistogram creates a histogram metric, or returns an error if the metric is invalid
+// or can't be registered
+func NewHistogram(name, help string, buckets []float64, opts ...Option) (HistogramMetric, error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	if err := o.validate(dto.MetricType_HISTOGRAM, name, nil); err != nil {
+		return HistogramMetric{}, err
+	}
+	if err := o.validHistogram(buckets, nil); err != nil {
+		return HistogramMetric{}, err
+	}
+	if err := o.native.valid(); err != nil {
+		return HistogramMetric{}, err
+	}
+	vec, err := register(o, name, prometheus.NewHistogramVec(o.histogramOpts(name, help, buckets), []string{}))
+	if err != nil {
+		return HistogramMetric{}, err
+	}
+	return HistogramMetric{observer: vec.WithLabelValues()}, nil
 }
 
 // HistogramWith creates a histogram metric with typed labels
-func HistogramWith[T any](name, help string, buckets []float64) HistogramMetricLabeled[T] {
-	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    mustValidMetricName(name),
-		Help:    help,
-		Buckets: buckets,
-	}, getLabelKeys[T]())
-	prometheus.MustRegister(vec)
-	return HistogramMetricLabeled[T]{vec: vec}
+func HistogramWith[T any](name, help string, buckets []float64, opts ...Option) HistogramMetricLabeled[T] {
+	h, err := NewHistogramWith[T](name, help, buckets, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// NewHistogramWith creates a histogram metric with typed labels, or returns an error if the metric
+// or its labels are invalid or it can't be registered
+func NewHistogramWith[T any](name, help string, buckets []float64, opts ...Option) (HistogramMetricLabeled[T], error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return HistogramMetricLabeled[T]{}, err
+	}
+	if err := o.validate(dto.MetricType_HISTOGRAM, name, ls.keys); err != nil {
+		return HistogramMetricLabeled[T]{}, err
+	}
+	if err := o.validHistogram(buckets, ls.keys); err != nil {
+		return HistogramMetricLabeled[T]{}, err
+	}
+	if err := o.native.valid(); err != nil {
+		return HistogramMetricLabeled[T]{}, err
+	}
+	vec, err := register(o, name, prometheus.NewHistogramVec(o.histogramOpts(name, help, buckets), ls.keys))
+	if err != nil {
+		return HistogramMetricLabeled[T]{}, err
+	}
+	return HistogramMetricLabeled[T]{
+		vec: vec,
+		labeled: newLabeledVec(o, name, ls, vec.MetricVec, vec.WithLabelValues, func() prometheus.Observer {
+			return prometheus.NewHistogram(o.histogramOpts(name, help, buckets))
+		}),
+	}, nil
+}
+
+// NativeHistogramOpts configures Prometheus native (sparse) histograms, which pick their bucket
+// boundaries automatically with a fixed relative resolution instead of relying on hand-picked buckets.
+// See https://prometheus.io/docs/specs/native_histograms/.
+type NativeHistogramOpts struct {
+	// BucketFactor is the maximum ratio between the upper bounds of two consecutive buckets,
+	// e.g. 1.1 for buckets at most 10% wide. Must be greater than 1.
+	BucketFactor float64
+
+	// MaxBucketNumber limits the number of buckets. Once exceeded, the histogram resolution is
+	// reduced or the histogram is reset, see MinResetDuration. Zero means no limit.
+	MaxBucketNumber uint32
+
+	// MinResetDuration is the minimum time between resets of a histogram that exceeded
+	// MaxBucketNumber. Zero means the histogram is never reset, only its resolution is reduced.
+	MinResetDuration time.Duration
+
+	// ZeroThreshold is the width of the zero bucket, in which all observations close to zero
+	// are counted. Zero uses the Prometheus default (2^-128).
+	ZeroThreshold float64
+}
+
+// WithNativeHistogram enables native histograms, either alone (with nil buckets) or alongside
+// classic buckets. Only applies to Histogram and HistogramWith.
+func WithNativeHistogram(native NativeHistogramOpts) Option {
+	return func(o *metricOpts) {
+		o.native = &native
+	}
+}
+
+func (n *NativeHistogramOpts) valid() error {
+	if n == nil {
+		return nil
+	}
+	if n.BucketFactor <= 1 {
+		return fmt.Errorf("invalid native histogram bucket factor: %v (must be greater than 1)", n.BucketFactor)
+	}
+	if n.ZeroThreshold < 0 {
+		return errors.New("invalid native histogram zero threshold: must not be negative")
+	}
+	return nil
+}
+
+func (o *metricOpts) histogramOpts(name, help string, buckets []float64) prometheus.HistogramOpts {
+	opts := prometheus.HistogramOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: o.constLabels,
+		Buckets:     buckets,
+	}
+	if o.native != nil {
+		opts.NativeHistogramBucketFactor = o.native.BucketFactor
+		opts.NativeHistogramMaxBucketNumber = o.native.MaxBucketNumber
+		opts.NativeHistogramMinResetDuration = o.native.MinResetDuration
+		opts.NativeHistogramZeroThreshold = o.native.ZeroThreshold
+	}
+	return opts
 }
 
 type HistogramMetric struct {
-	vec *prometheus.HistogramVec
+	observer prometheus.Observer
+
+	// bound looks up the series again on every use of handles of metrics with a TTL, see withBoundLabelValues.
+	bound func() prometheus.Observer
+}
+
+// metric returns the series of the handle.
+func (h *HistogramMetric) metric() prometheus.Observer {
+	if h.bound != nil {
+		return h.bound()
+	}
+	return h.observer
 }
 
 func (h *HistogramMetric) Observe(value float64) {
-	h.vec.With(prometheus.Labels{}).Observe(value)
+	h.metric().Observe(value)
+}
+
+// ObserveWithExemplar observes the value and attaches an exemplar, e.g. {"trace_id": "..."},
+// served in the OpenMetrics exposition format. If exemplar is nil, no exemplar is attached.
+// It panics if the exemplar labels are invalid or longer than 128 runes in total.
+func (h *HistogramMetric) ObserveWithExemplar(value float64, exemplar map[string]string) {
+	observeWithExemplar(h.metric(), value, exemplar)
+}
+
+// Snapshot returns the current count, sum and bucket counts of the histogram,
+// from which quantiles can be estimated, e.g. Snapshot().Quantile(0.99).
+func (h *HistogramMetric) Snapshot() HistogramSnapshot {
+	return newHistogramSnapshot(readMetric(h.metric().(prometheus.Metric)))
 }
 
 // HistogramMetric represents a histogram metric with typed labels
 type HistogramMetricLabeled[T any] struct {
-	vec *prometheus.HistogramVec
+	vec     *prometheus.HistogramVec
+	labeled *labeledVec[prometheus.Observer]
 }
 
 func (h *HistogramMetricLabeled[T]) Observe(value float64, labels T) {
-	h.vec.With(getLabelValues(labels)).Observe(value)
+	withLabelValues(h.labeled, labels).Observe(value)
+}
+
+// ObserveWithExemplar observes the value and attaches an exemplar, see HistogramMetric.ObserveWithExemplar.
+func (h *HistogramMetricLabeled[T]) ObserveWithExemplar(value float64, labels T, exemplar map[string]string) {
+	observeWithExemplar(withLabelValues(h.labeled, labels), value, exemplar)
+}
+
+// Snapshot returns the current count, sum and bucket counts of the histogram with the given labels,
+// or an empty snapshot if it doesn't exist. It doesn't create the series, see CounterMetricLabeled.Value.
+func (h *HistogramMetricLabeled[T]) Snapshot(labels T) HistogramSnapshot {
+	return newHistogramSnapshot(lookupLabelValues(h.labeled, labels))
+}
+
+// With returns the histogram for the given labels. The returned histogram can be cached
+// and used in hot paths to skip the label lookup on every call.
+func (h *HistogramMetricLabeled[T]) With(labels T) HistogramMetric {
+	observer, bound := withBoundLabelValues(h.labeled, labels)
+	return HistogramMetric{observer: observer, bound: bound}
+}
+
+// validHistogram checks the histogram configuration, which prometheus.NewHistogramVec would otherwise panic on.
+func (o *metricOpts) validHistogram(buckets []float64, keys []string) error {
+	if _, ok := o.constLabels["le"]; ok || slices.Contains(keys, "le") {
+		return errors.New(`label "le" is reserved for histogram buckets`)
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("histogram buckets must be in increasing order: %v >= %v", buckets[i-1], buckets[i])
+		}
+	}
+	return nil
+}
+
+// Init creates the series for all combinations of the allowed values of label fields restricted
+// by the `values` tag option, so that they are exported with zero values before the first observation.
+// Values of the other label fields are taken from labels.
+func (h *HistogramMetricLabeled[T]) Init(labels T) {
+	initLabelValues(h.labeled, labels)
+}
+
+// Delete deletes the series with the given labels, e.g. of a worker that disappeared,
+// and reports whether it existed.
+func (h *HistogramMetricLabeled[T]) Delete(labels T) bool {
+	return deleteLabelValues(h.labeled, labels)
+}
+
+// DeletePartialMatch deletes all series matching the non-zero fields of labels, e.g. all series
+// of a tenant, and returns the number of deleted series. If all fields are zero, all series are deleted.
+func (h *HistogramMetricLabeled[T]) DeletePartialMatch(labels T) int {
+	return deletePartialMatch(h.labeled, labels)
+}
+
+// Reset deletes all series.
+func (h *HistogramMetricLabeled[T]) Reset() {
+	resetLabelValues(h.labeled)
 }
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestNativeHistogram(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+
+	testCases := []struct {
+		name           string
+		buckets        []float64
+		classicBuckets int
+	}{
+		{name: "native only", buckets: nil, classicBuckets: 0},
+		{name: "native and classic", buckets: []float64{0.1, 1, 10}, classicBuckets: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := NewRegistry()
+			h := HistogramWith[jobLabels]("job_duration_seconds", "Job duration.", tc.buckets,
+				WithRegistry(reg),
+				WithNativeHistogram(NativeHistogramOpts{BucketFactor: 1.1, MaxBucketNumber: 100}),
+			)
+			h.Observe(0.5, jobLabels{Name: "a"})
+			h.Observe(2, jobLabels{Name: "a"})
+
+			families, err := reg.Gatherer().Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(families) != 1 || len(families[0].GetMetric()) != 1 {
+				t.Fatalf("expected a single histogram series, got %v", families)
+			}
+
+			hist := families[0].GetMetric()[0].GetHistogram()
+			if hist.Schema == nil {
+				t.Error("expected native histogram schema to be set")
+			}
+			if len(hist.GetPositiveSpan()) == 0 {
+				t.Error("expected native histogram positive spans")
+			}
+			if got := len(hist.GetBucket()); got != tc.classicBuckets {
+				t.Errorf("expected %d classic buckets, got %d", tc.classicBuckets, got)
+			}
+			if hist.GetSampleCount() != 2 {
+				t.Errorf("expected sample count 2, got %d", hist.GetSampleCount())
+			}
+		})
+	}
+}
+
+func TestNativeHistogramValidation(t *testing.T) {
+	reg := NewRegistry()
+
+	if _, err := NewHistogram("a_seconds", "A.", nil, WithRegistry(reg), WithNativeHistogram(NativeHistogramOpts{BucketFactor: 1})); err == nil {
+		t.Error("expected error for bucket factor <= 1")
+	}
+	if _, err := NewHistogram("b_seconds", "B.", nil, WithRegistry(reg), WithNativeHistogram(NativeHistogramOpts{BucketFactor: 1.1, ZeroThreshold: -1})); err == nil {
+		t.Error("expected error for negative zero threshold")
+	}
+}
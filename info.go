@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// InfoWith creates an info metric, which publishes metadata such as build or version information
+// as the typed labels of a single series with the value 1. It's served with the OpenMetrics info type,
+// or as a gauge in the Prometheus text format. The name must end with _info. If the metric is
+// reused with the WithReuse option, its labels are replaced by labels.
+func InfoWith[T any](name, help string, labels T, opts ...Option) InfoMetric[T] {
+	i, err := NewInfoWith(name, help, labels, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// NewInfoWith creates an info metric, or returns an error if the metric or its labels are invalid
+// or it can't be registered
+func NewInfoWith[T any](name, help string, labels T, opts ...Option) (InfoMetric[T], error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return InfoMetric[T]{}, err
+	}
+	if err := o.validate(dto.MetricType_GAUGE, name, ls.keys); err != nil {
+		return InfoMetric[T]{}, err
+	}
+	if err := validInfoName(name); err != nil {
+		return InfoMetric[T]{}, err
+	}
+
+	i := InfoMetric[T]{labels: ls}
+	if o.labelValuePolicy == LabelValuesReject && ls.restricted() {
+		i.reject = true
+		i.rejected = o.registry.rejectedCounter().With(rejectedLabels{Metric: name}).counter
+	}
+	values := labelValues(ls, nil, &labels)
+	if !ls.allow(values, i.reject) {
+		return InfoMetric[T]{}, fmt.Errorf("invalid labels of info metric %s: label values not allowed by the `values` tag option", name)
+	}
+
+	c, err := registerInfo(o, name, help, ls.keys, values)
+	if err != nil {
+		return InfoMetric[T]{}, err
+	}
+	i.collector = c
+	return i, nil
+}
+
+// validInfoName checks that the name of an info metric ends with _info.
+func validInfoName(name string) error {
+	if !strings.HasSuffix(name, "_info") {
+		return fmt.Errorf("invalid info metric name %s: info metric names must end with _info, rename it to %s_info", name, name)
+	}
+	return nil
+}
+
+// registerInfo registers the collector of an info metric with the given label values.
+// The label values of a collector reused due to the WithReuse option are replaced.
+func registerInfo(o metricOpts, name, help string, keys, values []string) (*infoCollector, error) {
+	o.openMetricsType = "info"
+	c, err := register(o, name, &infoCollector{
+		desc:   prometheus.NewDesc(name, help, keys, o.constLabels),
+		values: values,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.set(values)
+	return c, nil
+}
+
+// InfoMetric represents an info metric with typed labels
+type InfoMetric[T any] struct {
+	collector *infoCollector
+	labels    *labelInfo
+
+	// reject keeps the labels if the new label values are not allowed, and counts them by the rejected counter.
+	reject   bool
+	rejected prometheus.Counter
+}
+
+// Set replaces the labels of the info metric, e.g. after a configuration reload. Label values
+// that are not allowed are replaced by fallback values or rejected, like for other metric types.
+func (i *InfoMetric[T]) Set(labels T) {
+	values := labelValues(i.labels, nil, &labels)
+	if !i.labels.allow(values, i.reject) {
+		i.rejected.Inc()
+		return
+	}
+	i.collector.set(values)
+}
+
+// infoCollector collects the single series of an info metric.
+type infoCollector struct {
+	desc *prometheus.Desc
+
+	mu     sync.Mutex
+	values []string
+}
+
+func (c *infoCollector) set(values []string) {
+	c.mu.Lock()
+	c.values = values
+	c.mu.Unlock()
+}
+
+func (c *infoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *infoCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	values := c.values
+	c.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, values...)
+}
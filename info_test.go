@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInfo(t *testing.T) {
+	type buildLabels struct {
+		Version   string `label:"version"`
+		GoVersion string `label:"go_version"`
+	}
+
+	reg := NewRegistry()
+	info := InfoWith("build_info", "Build information.", buildLabels{Version: "v1.0.0", GoVersion: "go1.24"}, WithRegistry(reg))
+
+	om := scrapeOpenMetrics(t, reg.Handler())
+	for _, want := range []string{
+		"# HELP build Build information.\n# TYPE build info\nbuild_info{go_version=\"go1.24\",version=\"v1.0.0\"} 1",
+		"# EOF",
+	} {
+		if !strings.Contains(om, want) {
+			t.Errorf("expected %q in OpenMetrics output:\n%s", want, om)
+		}
+	}
+
+	info.Set(buildLabels{Version: "v1.1.0", GoVersion: "go1.24"})
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, "# TYPE build_info gauge\nbuild_info{go_version=\"go1.24\",version=\"v1.1.0\"} 1\n") || strings.Contains(body, "v1.0.0") {
+		t.Errorf("expected replaced info series in output:\n%s", body)
+	}
+
+	if _, err := NewInfoWith("build", "Build information.", buildLabels{}, WithRegistry(reg)); err == nil || !strings.Contains(err.Error(), "build_info") {
+		t.Errorf("expected error for info name without _info suffix, got %v", err)
+	}
+}
+
+func TestInfoAllowedValues(t *testing.T) {
+	type envLabels struct {
+		Environment string `label:"environment,values=production|staging"`
+	}
+
+	reg := NewRegistry()
+	info := InfoWith("env_info", "Environment.", envLabels{Environment: "dev"}, WithRegistry(reg))
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, `env_info{environment="other"} 1`) {
+		t.Errorf("expected fallback value in output:\n%s", body)
+	}
+
+	// Reused info metrics replace the labels.
+	InfoWith("env_info", "Environment.", envLabels{Environment: "staging"}, WithRegistry(reg), WithReuse())
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, `env_info{environment="staging"} 1`) {
+		t.Errorf("expected reused info labels in output:\n%s", body)
+	}
+	info.Set(envLabels{Environment: "production"})
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, `env_info{environment="production"} 1`) {
+		t.Errorf("expected replaced info labels in output:\n%s", body)
+	}
+
+	rejecting := InfoWith("region_info", "Environment.", envLabels{Environment: "staging"}, WithRegistry(reg), WithLabelValuePolicy(LabelValuesReject))
+	rejecting.Set(envLabels{Environment: "dev"})
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`region_info{environment="staging"} 1`,
+		`metrics_rejected_observations_total{metric="region_info"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+	if _, err := NewInfoWith("other_info", "Environment.", envLabels{Environment: "dev"}, WithRegistry(reg), WithLabelValuePolicy(LabelValuesReject)); err == nil {
+		t.Error("expected error for rejected info labels")
+	}
+}
+
+func TestStateSet(t *testing.T) {
+	type circuitState string
+	type circuitLabels struct {
+		Circuit string `label:"circuit"`
+	}
+
+	reg := NewRegistry()
+	state := StateSetWith[circuitLabels]("circuit_state", "Circuit breaker state.", []circuitState{"closed", "open", "half_open"}, WithRegistry(reg))
+
+	state.Set("closed", circuitLabels{Circuit: "payments"})
+	state.Set("open", circuitLabels{Circuit: "payments"})
+	state.Set("closed", circuitLabels{Circuit: "users"})
+	state.Set("unknown", circuitLabels{Circuit: "users"}) // Ignored.
+	state.Set("closed", circuitLabels{Circuit: "gone"})
+	if !state.Delete(circuitLabels{Circuit: "gone"}) {
+		t.Error("expected Delete to report the deleted series")
+	}
+
+	om := scrapeOpenMetrics(t, reg.Handler())
+	for _, want := range []string{
+		"# HELP circuit_state Circuit breaker state.\n# TYPE circuit_state stateset\n",
+		`circuit_state{circuit="payments",circuit_state="closed"} 0.0`,
+		`circuit_state{circuit="payments",circuit_state="open"} 1.0`,
+		`circuit_state{circuit="payments",circuit_state="half_open"} 0.0`,
+		`circuit_state{circuit="users",circuit_state="closed"} 1.0`,
+		`circuit_state{circuit="users",circuit_state="open"} 0.0`,
+	} {
+		if !strings.Contains(om, want) {
+			t.Errorf("expected %q in OpenMetrics output:\n%s", want, om)
+		}
+	}
+	if strings.Contains(om, "gone") {
+		t.Errorf("unexpected deleted series in output:\n%s", om)
+	}
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, "# TYPE circuit_state gauge\n") || !strings.Contains(body, `circuit_state{circuit="payments",circuit_state="open"} 1`) {
+		t.Errorf("expected gauge in text output:\n%s", body)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		states []circuitState
+	}{
+		{"circuit_state_dup", []circuitState{"open", "open"}},
+		{"circuit_state_empty", nil},
+		{"circuit", []circuitState{"open"}}, // Clashes with the circuit label.
+	} {
+		if _, err := NewStateSetWith[circuitLabels](tc.name, "Invalid.", tc.states, WithRegistry(reg)); err == nil {
+			t.Errorf("expected error for state set %s", tc.name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// LabelValuePolicy determines what happens to observations with label values not allowed
+// by the `values` tag option, e.g. `label:"status,values=ok|error|timeout"`.
+type LabelValuePolicy int
+
+const (
+	// LabelValuesFallback replaces label values that are not allowed with the fallback value
+	// of the field, which is OtherLabelValue unless set by the `fallback` tag option. This is the default.
+	LabelValuesFallback LabelValuePolicy = iota
+
+	// LabelValuesReject drops observations with label values that are not allowed,
+	// and counts them by the metrics_rejected_observations_total metric. Handles returned
+	// by With are counted once, when they're created.
+	LabelValuesReject
+)
+
+// WithLabelValuePolicy sets what happens to observations with label values not allowed
+// by the `values` tag option. Defaults to LabelValuesFallback.
+func WithLabelValuePolicy(policy LabelValuePolicy) Option {
+	return func(o *metricOpts) {
+		o.labelValuePolicy = policy
+	}
+}
+
+// labeledVec holds the state shared by all labeled metric types: how to get label values
+// from label structs, and how to get the series (child metric) M for these label values.
+type labeledVec[M any] struct {
+	labels *labelInfo
+	vec    *prometheus.MetricVec
+	series *seriesTracker
+	with   func(lvs ...string) M
+
+	// reject drops observations with label values that are not allowed into the discard metric,
+	// which isn't registered, and counts them by the rejected counter.
+	reject   bool
+	rejected prometheus.Counter
+	discard  M
+}
+
+// newLabeledVec creates the labeled state of the registered vector vec. The with function looks up
+// the series of vec by label values, the discard function creates an unregistered metric of the same type.
+func newLabeledVec[M any](o metricOpts, name string, ls *labelInfo, vec *prometheus.MetricVec, with func(lvs ...string) M, discard func() M) *labeledVec[M] {
+	l := &labeledVec[M]{
+		labels: ls,
+		vec:    vec,
+		series: o.registry.seriesTracker(vec, o, name, ls.keys),
+		with:   with,
+	}
+	if o.labelValuePolicy == LabelValuesReject && ls.restricted() {
+		l.reject = true
+		l.rejected = o.registry.rejectedCounter().With(rejectedLabels{Metric: name}).counter
+		l.discard = discard()
+	}
+	return l
+}
+
+// labelScratch is a reusable buffer for withLabelValues.
+type labelScratch[T any] struct {
+	labels T
+	values []string
+}
+
+// withLabelValues returns the series for the given label struct. Label values that are not
+// allowed are replaced by fallback values or rejected, and new label sets over the series
+// limit are folded into the overflow series.
+//
+// The label struct is copied into a pooled buffer, so that it doesn't escape to the heap
+// via reflect or the generated label methods, and the unbound metric methods, e.g. CounterMetricLabeled.Inc, don't allocate.
+// The Prometheus vectors copy the label values when creating a new series, so the buffer
+// can be reused once the series is found.
+func withLabelValues[T any, M any](l *labeledVec[M], labels T) M {
+	ls := l.labels
+	s := ls.scratch.Get().(*labelScratch[T])
+	s.labels = labels
+	s.values = labelValues(ls, s.values[:0], &s.labels)
+
+	m := l.discard
+	if l.allow(s.values) {
+		m = l.with(l.series.track(s.values)...)
+	}
+
+	var zero T
+	s.labels = zero
+	clear(s.values)
+	ls.scratch.Put(s)
+
+	return m
+}
+
+// withBoundLabelValues returns the series for a handle returned by With, see withLabelValues. For metrics
+// with a TTL, it also returns a function that looks up the series again, which the handle calls on every use,
+// so that updates through the handle keep the series from expiring, and recreate it once it expired.
+func withBoundLabelValues[T any, M any](l *labeledVec[M], labels T) (M, func() M) {
+	m := withLabelValues(l, labels)
+	if l.series == nil || l.series.ttl <= 0 {
+		return m, nil
+	}
+	values := labelValues(l.labels, nil, &labels)
+	if !l.labels.allow(values, l.reject) {
+		// Handles of rejected label values update the discard metric.
+		return m, nil
+	}
+	return m, func() M {
+		return l.with(l.series.track(values)...)
+	}
+}
+
+// allow replaces label values that are not allowed by their fallback values, and reports
+// whether the label values can be recorded, i.e. they were not rejected.
+func (l *labeledVec[M]) allow(values []string) bool {
+	if !l.labels.allow(values, l.reject) {
+		l.rejected.Inc()
+		return false
+	}
+	return true
+}
+
+// initLabelValues creates the series for all combinations of the allowed values of label fields
+// restricted by the `values` tag option. Values of the other label fields are taken from labels.
+func initLabelValues[T any, M any](l *labeledVec[M], labels T) {
+	ls := l.labels
+	values := labelValues(ls, nil, &labels)
+
+	var init func(i int)
+	init = func(i int) {
+		if i == len(values) {
+			l.with(l.series.track(values)...)
+			return
+		}
+		if ls.fields[i].allowed == nil {
+			init(i + 1)
+			return
+		}
+		for _, value := range ls.fields[i].allowed {
+			values[i] = value
+			init(i + 1)
+		}
+	}
+	init(0)
+}
+
+// deleteLabelValues deletes the series for the given label struct and reports whether it existed.
+func deleteLabelValues[T any, M any](l *labeledVec[M], labels T) bool {
+	values := labelValues(l.labels, nil, &labels)
+	if !l.labels.allow(values, l.reject) {
+		return false
+	}
+	l.series.untrack(values)
+	return l.vec.DeleteLabelValues(values...)
+}
+
+// deletePartialMatch deletes all series matching the non-zero fields of the label struct
+// and returns the number of deleted series.
+func deletePartialMatch[T any, M any](l *labeledVec[M], labels T) int {
+	partial := nonZeroLabels(l.labels, &labels)
+	l.series.untrackMatching(partial)
+	return l.vec.DeletePartialMatch(partial)
+}
+
+// resetLabelValues deletes all series.
+func resetLabelValues[M any](l *labeledVec[M]) {
+	l.series.reset()
+	l.vec.Reset()
+}
+
+// rejectedLabels defines labels for the counter of rejected observations.
+type rejectedLabels struct {
+	Metric string `label:"metric"`
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+type jobStatusLabels struct {
+	Name   string `label:"name"`
+	Status string `label:"status,values=ok|error|timeout"`
+	Region string `label:"region,values=eu|us,fallback=unknown"`
+}
+
+func TestAllowedLabelValuesFallback(t *testing.T) {
+	reg := NewRegistry()
+	c := CounterWith[jobStatusLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+
+	c.Inc(jobStatusLabels{Name: "a", Status: "ok", Region: "eu"})
+	c.Inc(jobStatusLabels{Name: "a", Status: "crashed", Region: "eu"})
+	c.Inc(jobStatusLabels{Name: "a", Status: "ok", Region: "mars"})
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`jobs_total{name="a",region="eu",status="ok"} 1`,
+		`jobs_total{name="a",region="eu",status="other"} 1`,
+		`jobs_total{name="a",region="unknown",status="ok"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "crashed") || strings.Contains(body, "mars") {
+		t.Errorf("unexpected label values in output:\n%s", body)
+	}
+}
+
+func TestAllowedLabelValuesReject(t *testing.T) {
+	reg := NewRegistry()
+	h := HistogramWith[jobStatusLabels]("job_duration_seconds", "Job duration.", []float64{1}, WithRegistry(reg), WithLabelValuePolicy(LabelValuesReject))
+
+	h.Observe(0.5, jobStatusLabels{Name: "a", Status: "ok", Region: "eu"})
+	h.Observe(0.5, jobStatusLabels{Name: "a", Status: "crashed", Region: "eu"})
+	bound := h.With(jobStatusLabels{Name: "a", Status: "ok", Region: "mars"})
+	bound.Observe(0.5)
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`job_duration_seconds_count{name="a",region="eu",status="ok"} 1`,
+		`metrics_rejected_observations_total{metric="job_duration_seconds"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "crashed") || strings.Contains(body, "mars") || strings.Contains(body, "other") {
+		t.Errorf("unexpected label values in output:\n%s", body)
+	}
+}
+
+func TestInitAllowedLabelValues(t *testing.T) {
+	reg := NewRegistry()
+	c := CounterWith[jobStatusLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+
+	c.Init(jobStatusLabels{Name: "a"})
+
+	families, err := reg.Gatherer().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(families[0].GetMetric()); got != 6 {
+		t.Errorf("expected 3 statuses x 2 regions = 6 series, got %d", got)
+	}
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, `jobs_total{name="a",region="us",status="timeout"} 0`) {
+		t.Errorf("expected pre-created series in output:\n%s", body)
+	}
+}
@@ -1,57 +1,114 @@
 package metrics
 
 import (
+	"encoding"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	labelCache sync.Map // map[reflect.Type][]string
-)
+	labelCache sync.Map // map[reflect.Type]*labelInfo
 
-// isValidLabelName checks if a label name matches the Prometheus format [a-z_][a-z0-9_]*.
-func isValidLabelName(s string) bool {
-	if len(s) == 0 {
-		return false
-	}
+	stringerType      = reflect.TypeFor[fmt.Stringer]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
 
-	// First character must be [a-z_]
-	if s[0] != '_' && (s[0] < 'a' || s[0] > 'z') {
-		return false
-	}
+// labelInfo describes a label struct type: its label keys and how to format its fields.
+type labelInfo struct {
+	keys   []string
+	fields []labelField
 
-	// Remaining characters must be [a-z0-9_]
-	for i := 1; i < len(s); i++ {
-		c := s[i]
-		if c != '_' && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
-			return false
-		}
-	}
+	// generated is set for label structs implementing GeneratedLabels, whose label values
+	// are appended by the generated AppendLabelValues method instead of by reflection.
+	generated bool
+	// zeroValues are the label values of the zero label struct, if generated.
+	zeroValues []string
 
-	return true
+	// scratch holds reusable *labelScratch[T] buffers, see withLabelValues.
+	scratch sync.Pool
 }
 
-// mustValidMetricName validates a metric name and panics if it doesn't match the required Prometheus format.
-func mustValidMetricName(s string) string {
-	if !isValidLabelName(s) {
-		panic("invalid metric name: " + s + " (must match [a-z_][a-z0-9_]*)")
-	}
-	return s
+// labelField describes a single label struct field.
+type labelField struct {
+	index []int
+	kind  labelKind
+
+	// allowed lists the allowed label values, if restricted by the `values` tag option.
+	allowed []string
+	// fallback replaces label values that are not allowed, see LabelValuesFallback.
+	fallback string
+	// defaultValue replaces empty label values, see the `default` tag option.
+	defaultValue string
+	// maxLen is the maximum length of label values in bytes, see the `maxlen` tag option.
+	maxLen int
+	// sanitize replaces control characters in label values, see the `sanitize` tag option.
+	sanitize bool
 }
 
+// OtherLabelValue is the default value that replaces label values not allowed by the `values` tag option,
+// e.g. `label:"status,values=ok|error|timeout"`. Use the `fallback` tag option to override it.
+const OtherLabelValue = "other"
+
+// labelKind determines how a label struct field is formatted as a label value.
+type labelKind uint8
+
+const (
+	labelKindInvalid labelKind = iota
+	labelKindString
+	labelKindInt
+	labelKindUint
+	labelKindBool
+	labelKindStringer
+	labelKindTextMarshaler
+)
+
 // getLabelKeys returns the list of labels defined as struct tags, e.g. `label:"some_name"`,
 // and panics if any of the labels are empty or invalid.
-// This function implements memoization - it computes the keys once per type and caches them.
 func getLabelKeys[T any]() []string {
+	return mustLabelInfo[T]().keys
+}
+
+// mustLabelInfo is like getLabelInfo, but panics if any of the labels are empty or invalid.
+func mustLabelInfo[T any]() *labelInfo {
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		panic(err)
+	}
+	return ls
+}
+
+// getLabelInfo parses the labels defined as struct tags, e.g. `label:"some_name"`,
+// and returns an error if any of the labels are empty or invalid.
+// This function implements memoization - it computes the label info once per type and caches it.
+// Label structs implementing GeneratedLabels are described by their generated methods instead.
+func getLabelInfo[T any]() (*labelInfo, error) {
 	var zero T
 	t := reflect.TypeOf(zero)
+	if t == nil {
+		return nil, errors.New("invalid label type: interface (must be struct)")
+	}
 
 	cached, ok := labelCache.Load(t)
 	if ok {
-		return cached.([]string)
+		return cached.(*labelInfo), nil
+	}
+
+	if g, ok := any(&zero).(GeneratedLabels[T]); ok {
+		ls, err := newGeneratedLabelInfo[T](g)
+		if err != nil {
+			return nil, err
+		}
+		labelCache.Store(t, ls)
+		return ls, nil
 	}
 
 	structType := t
@@ -60,58 +117,354 @@ func getLabelKeys[T any]() []string {
 	}
 
 	if structType.Kind() != reflect.Struct {
-		panic("invalid label type: " + structType.Kind().String() + " (must be struct)")
+		return nil, errors.New("invalid label type: " + structType.Kind().String() + " (must be struct)")
+	}
+
+	ls := &labelInfo{}
+	ls.scratch.New = func() any { return new(labelScratch[T]) }
+	if err := ls.addFields(structType, nil, map[string]string{}); err != nil {
+		return nil, err
 	}
 
-	var keys []string
+	labelCache.Store(t, ls)
+
+	return ls, nil
+}
+
+// addFields adds the label fields of structType, found at the given index path within the label struct.
+// Anonymous embedded structs without a `label` tag are flattened into the label set, and fields
+// tagged with `label:"-"` are skipped. The seen map tracks label names to the fields defining them.
+func (ls *labelInfo) addFields(structType reflect.Type, index []int, seen map[string]string) error {
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
 
-		labelName := field.Tag.Get("label")
-		if labelName == "" {
-			panic(fmt.Sprintf("missing `label` struct tag (package %v):\ntype %s %s {\n\t%s %s `label:\"add_label_here\"`\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type))
+		labelTag := field.Tag.Get("label")
+		if labelTag == "-" {
+			continue
 		}
+		if labelTag == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if !field.IsExported() {
+				return fmt.Errorf("embedded label structs must be exported (package %v):\ntype %s %s {\n\t%s // <-- embedded struct must be exported\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Type)
+			}
+			if err := ls.addFields(field.Type, append(slices.Clip(index), i), seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if labelTag == "" && field.Anonymous && field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+			return fmt.Errorf("embedded label structs must not be pointers (package %v):\ntype %s %s {\n\t%s // <-- embed %s instead\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Type, field.Type.Elem())
+		}
+
+		if labelTag == "" {
+			return fmt.Errorf("missing `label` struct tag (package %v):\ntype %s %s {\n\t%s %s `label:\"add_label_here\"`\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type)
+		}
+		tag, err := parseLabelTag(labelTag)
+		if err != nil {
+			return fmt.Errorf("invalid `label` struct tag (package %v):\ntype %s %s {\n\t%s %s `label:\"%s\"` // <-- %v\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelTag, err)
+		}
+		labelName := tag.name
 		if !isValidLabelName(labelName) {
-			panic(fmt.Sprintf("invalid `label` name (package %v):\ntype %s %s {\n\t%s %s `label:\"%s\"` // <-- label must match [a-z_][a-z0-9_]*\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelName))
+			return fmt.Errorf("invalid `label` name (package %v):\ntype %s %s {\n\t%s %s `label:\"%s\"` // <-- label %s\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelTag, loadNameValidation().labelNameRule())
 		}
 		if !field.IsExported() {
-			panic(fmt.Sprintf("label struct fields must be exported (package %v):\ntype %s %s {\n\t%s %s `label:\"%v\"` // <-- field must be exported\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelName))
+			return fmt.Errorf("label struct fields must be exported (package %v):\ntype %s %s {\n\t%s %s `label:\"%v\"` // <-- field must be exported\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelTag)
+		}
+
+		kind := labelKindOf(field.Type)
+		if kind == labelKindInvalid {
+			return fmt.Errorf("unsupported label struct field type (package %v):\ntype %s %s {\n\t%s %s `label:\"%v\"` // <-- field must be string, int, uint, bool, fmt.Stringer or encoding.TextMarshaler\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelTag)
+		}
+
+		fieldName := structType.Name() + "." + field.Name
+		if other, ok := seen[labelName]; ok {
+			return fmt.Errorf("duplicate `label` name %q (package %v):\ntype %s %s {\n\t%s %s `label:\"%v\"` // <-- label already defined by %s\n}", labelName, structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelTag, other)
+		}
+		seen[labelName] = fieldName
+
+		ls.keys = append(ls.keys, labelName)
+		ls.fields = append(ls.fields, labelField{
+			index:        append(slices.Clip(index), i),
+			kind:         kind,
+			allowed:      tag.values,
+			fallback:     tag.fallback,
+			defaultValue: tag.defaultValue,
+			maxLen:       tag.maxLen,
+			sanitize:     tag.sanitize,
+		})
+	}
+
+	return nil
+}
+
+// labelTag is a parsed `label` struct tag, e.g. `label:"status,values=ok|error|timeout,fallback=unknown"`.
+type labelTag struct {
+	name         string
+	values       []string
+	fallback     string
+	defaultValue string
+	maxLen       int
+	sanitize     bool
+}
+
+// parseLabelTag parses a `label` struct tag. The first comma-separated element is the label name,
+// the rest are options:
+//   - values=a|b|c: the allowed label values
+//   - fallback=x: the value replacing label values that are not allowed (defaults to OtherLabelValue)
+//   - default=x: the value replacing empty label values
+//   - maxlen=n: truncates label values to at most n bytes
+//   - sanitize: replaces control characters in label values with U+FFFD
+func parseLabelTag(s string) (labelTag, error) {
+	name, opts, _ := strings.Cut(s, ",")
+	tag := labelTag{name: name}
+
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "values":
+			if value == "" {
+				return labelTag{}, errors.New("values option must list allowed values, e.g. values=a|b|c")
+			}
+			tag.values = strings.Split(value, "|")
+		case "fallback":
+			tag.fallback = value
+		case "default":
+			if value == "" {
+				return labelTag{}, errors.New("default option must set a value, e.g. default=unknown")
+			}
+			tag.defaultValue = value
+		case "maxlen":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return labelTag{}, errors.New("maxlen option must be a positive number, e.g. maxlen=64")
+			}
+			tag.maxLen = n
+		case "sanitize":
+			tag.sanitize = true
+		default:
+			return labelTag{}, fmt.Errorf("unknown option %q", key)
 		}
+	}
+
+	if tag.fallback != "" && tag.values == nil {
+		return labelTag{}, errors.New("fallback option requires the values option")
+	}
+	if tag.values != nil && tag.fallback == "" {
+		tag.fallback = OtherLabelValue
+	}
+
+	return tag, nil
+}
+
+// restricted reports whether any label values are restricted by the `values` tag option.
+func (ls *labelInfo) restricted() bool {
+	return slices.ContainsFunc(ls.fields, func(f labelField) bool { return f.allowed != nil })
+}
+
+// allows reports whether the label value is allowed by the `values` tag option.
+func (f *labelField) allows(value string) bool {
+	return f.allowed == nil || slices.Contains(f.allowed, value)
+}
+
+// labelKindOf returns how values of the given type are formatted as a label value,
+// or labelKindInvalid if the type can't be used as a label. Types implementing
+// encoding.TextMarshaler or fmt.Stringer are formatted by these methods, other types by their kind.
+func labelKindOf(t reflect.Type) labelKind {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		// Nil values can't be formatted.
+		return labelKindInvalid
+	}
+
+	switch {
+	case t.Implements(textMarshalerType):
+		return labelKindTextMarshaler
+	case t.Implements(stringerType):
+		return labelKindStringer
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return labelKindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return labelKindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return labelKindUint
+	case reflect.Bool:
+		return labelKindBool
+	}
 
-		ft := field.Type
-		if ft.Kind() != reflect.String {
-			panic(fmt.Sprintf("label struct fields must be strings (package %v):\ntype %s %s {\n\t%s %s `label:\"%v\"` // <-- field must be string\n}", structType.PkgPath(), structType.Name(), structType.Kind(), field.Name, field.Type, labelName))
+	return labelKindInvalid
+}
+
+// value returns the label value of the field value v: formatted, with the default value
+// applied, valid UTF-8 (which Prometheus requires), sanitized and truncated as configured.
+func (f *labelField) value(v reflect.Value) string {
+	return f.normalize(f.format(v))
+}
+
+// normalize applies the default value to the formatted label value s, and makes it
+// valid UTF-8, sanitized and truncated as configured.
+func (f *labelField) normalize(s string) string {
+	if s == "" && f.defaultValue != "" {
+		return f.defaultValue
+	}
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	if f.sanitize && hasControlChars(s) {
+		s = strings.Map(replaceControlChar, s)
+	}
+	if f.maxLen > 0 && len(s) > f.maxLen {
+		s = truncate(s, f.maxLen)
+	}
+	return s
+}
+
+func hasControlChars(s string) bool {
+	return strings.ContainsFunc(s, unicode.IsControl)
+}
+
+func replaceControlChar(r rune) rune {
+	if unicode.IsControl(r) {
+		return utf8.RuneError
+	}
+	return r
+}
+
+// truncate shortens the valid UTF-8 string s to at most n bytes, without splitting a rune.
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// format formats the field value v as a label value. Common values are formatted without allocations.
+func (f *labelField) format(v reflect.Value) string {
+	switch f.kind {
+	case labelKindString:
+		return v.String()
+	case labelKindInt:
+		return formatInt(v.Int())
+	case labelKindUint:
+		return formatUint(v.Uint())
+	case labelKindBool:
+		return strconv.FormatBool(v.Bool())
+	case labelKindStringer:
+		return v.Interface().(fmt.Stringer).String()
+	case labelKindTextMarshaler:
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return ""
 		}
-		keys = append(keys, labelName)
+		return string(text)
 	}
+	panic("unreachable, invalid label kind")
+}
 
-	labelCache.Store(t, keys)
+// smallInts holds preformatted integers, so that common label values
+// such as HTTP status codes are formatted without allocations.
+var smallInts = func() (s [1000]string) {
+	for i := range s {
+		s[i] = strconv.Itoa(i)
+	}
+	return s
+}()
 
-	return keys
+func formatInt(i int64) string {
+	if i >= 0 && i < int64(len(smallInts)) {
+		return smallInts[i]
+	}
+	return strconv.FormatInt(i, 10)
+}
+
+func formatUint(u uint64) string {
+	if u < uint64(len(smallInts)) {
+		return smallInts[u]
+	}
+	return strconv.FormatUint(u, 10)
 }
 
-// getLabelValues extracts label values from a struct instance using the cached label keys.
-// This function assumes that getLabelKeys[T]() has been called first to populate the cache.
+// getLabelValues extracts label values from a struct instance. It panics if T isn't a valid label struct.
 func getLabelValues[T any](labelStruct T) prometheus.Labels {
-	v := reflect.ValueOf(labelStruct)
-	t := v.Type()
+	ls := mustLabelInfo[T]()
+	values := labelValues(ls, nil, &labelStruct)
 
-	cached, ok := labelCache.Load(t)
-	if !ok {
-		panic("unreachable, getLabelKeys should have cached the keys")
+	labels := make(prometheus.Labels, len(ls.keys))
+	for i, key := range ls.keys {
+		labels[key] = values[i]
 	}
-	keys := cached.([]string)
 
-	structValue := v
-	if v.Kind() == reflect.Ptr {
-		structValue = v.Elem()
+	return labels
+}
+
+// allow replaces label values that are not allowed by the `values` tag option by their fallback
+// values. If reject is set, it reports false instead.
+func (ls *labelInfo) allow(values []string, reject bool) bool {
+	for i := range ls.fields {
+		field := &ls.fields[i]
+		if field.allows(values[i]) {
+			continue
+		}
+		if reject {
+			return false
+		}
+		values[i] = field.fallback
 	}
+	return true
+}
 
-	labels := make(prometheus.Labels, len(keys))
-	for i, key := range keys {
-		valField := structValue.Field(i)
-		labels[key] = valField.String()
+// nonZeroLabels returns the labels of the non-zero fields of the label struct. Fields of generated
+// label structs are zero if their label values equal the label values of the zero label struct.
+func nonZeroLabels[T any](ls *labelInfo, labelStruct *T) prometheus.Labels {
+	labels := prometheus.Labels{}
+	if ls.generated {
+		for i, value := range labelValues(ls, nil, labelStruct) {
+			if value != ls.zeroValues[i] {
+				labels[ls.keys[i]] = value
+			}
+		}
+		return labels
 	}
 
+	v := reflect.ValueOf(labelStruct).Elem()
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	for i := range ls.fields {
+		field := &ls.fields[i]
+		if fv := v.FieldByIndex(field.index); !fv.IsZero() {
+			labels[ls.keys[i]] = field.value(fv)
+		}
+	}
 	return labels
 }
+
+// labelValues appends the ordered label values of the label struct to dst, by its generated
+// AppendLabelValues method if it implements GeneratedLabels, or otherwise by reflection.
+func labelValues[T any](ls *labelInfo, dst []string, labelStruct *T) []string {
+	if ls.generated {
+		start := len(dst)
+		dst = any(labelStruct).(GeneratedLabels[T]).AppendLabelValues(dst)
+		for i := range ls.fields {
+			dst[start+i] = ls.fields[i].normalize(dst[start+i])
+		}
+		return dst
+	}
+	return ls.appendValues(dst, reflect.ValueOf(labelStruct).Elem())
+}
+
+// appendValues appends the ordered label values of the label struct value v to dst.
+func (ls *labelInfo) appendValues(dst []string, v reflect.Value) []string {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	for i := range ls.fields {
+		field := &ls.fields[i]
+		dst = append(dst, field.value(v.FieldByIndex(field.index)))
+	}
+	return dst
+}
@@ -1,8 +1,11 @@
 package metrics
 
 import (
+	"fmt"
+	"net/netip"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestSimpleStringLabels(t *testing.T) {
@@ -116,6 +119,163 @@ func TestComplexLabels(t *testing.T) {
 	}
 }
 
+type testEnum string
+
+type testState int
+
+func (s testState) String() string {
+	switch s {
+	case 1:
+		return "running"
+	default:
+		return "idle"
+	}
+}
+
+func TestTypedLabels(t *testing.T) {
+	type typedLabels struct {
+		Status   int           `label:"status"`
+		Code     int64         `label:"code"`
+		Retries  uint8         `label:"retries"`
+		Cached   bool          `label:"cached"`
+		Kind     testEnum      `label:"kind"`
+		State    testState     `label:"state"`
+		Timeout  time.Duration `label:"timeout"`
+		RemoteIP netip.Addr    `label:"remote_ip"`
+	}
+
+	getLabelKeys[typedLabels]()
+
+	result := getLabelValues(typedLabels{
+		Status:   200,
+		Code:     -12345,
+		Retries:  3,
+		Cached:   true,
+		Kind:     "batch",
+		State:    1,
+		Timeout:  5 * time.Second,
+		RemoteIP: netip.MustParseAddr("10.0.0.1"),
+	})
+
+	expected := map[string]string{
+		"status":    "200",
+		"code":      "-12345",
+		"retries":   "3",
+		"cached":    "true",
+		"kind":      "batch",
+		"state":     "running",
+		"timeout":   "5s",
+		"remote_ip": "10.0.0.1",
+	}
+
+	for key, expectedValue := range expected {
+		if result[key] != expectedValue {
+			t.Errorf("Expected %s to be %q, got %q", key, expectedValue, result[key])
+		}
+	}
+}
+
+func TestLabelValueDefaultsAndSanitization(t *testing.T) {
+	type requestLabels struct {
+		Region string `label:"region,default=unknown"`
+		Path   string `label:"path,maxlen=8"`
+		Agent  string `label:"agent,sanitize"`
+		Raw    string `label:"raw"`
+	}
+
+	getLabelKeys[requestLabels]()
+
+	testCases := []struct {
+		name     string
+		labels   requestLabels
+		expected map[string]string
+	}{
+		{
+			name:     "defaults",
+			labels:   requestLabels{},
+			expected: map[string]string{"region": "unknown", "path": "", "agent": "", "raw": ""},
+		},
+		{
+			name:     "valid values",
+			labels:   requestLabels{Region: "eu", Path: "/api", Agent: "curl/8.0", Raw: "raw"},
+			expected: map[string]string{"region": "eu", "path": "/api", "agent": "curl/8.0", "raw": "raw"},
+		},
+		{
+			name:     "truncated",
+			labels:   requestLabels{Path: "/api/users/123"},
+			expected: map[string]string{"path": "/api/use"},
+		},
+		{
+			name:     "truncated at rune boundary",
+			labels:   requestLabels{Path: "/api/x€uro"}, // € is 3 bytes long
+			expected: map[string]string{"path": "/api/x"},
+		},
+		{
+			name:     "control characters",
+			labels:   requestLabels{Agent: "evil\n\x00agent"},
+			expected: map[string]string{"agent": "evil\ufffd\ufffdagent"},
+		},
+		{
+			name:     "invalid utf-8",
+			labels:   requestLabels{Agent: "bad\xffagent", Raw: "bad\xff\xferaw"},
+			expected: map[string]string{"agent": "bad\ufffdagent", "raw": "bad\ufffdraw"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := getLabelValues(tc.labels)
+			for key, expectedValue := range tc.expected {
+				if result[key] != expectedValue {
+					t.Errorf("Expected %s to be %q, got %q", key, expectedValue, result[key])
+				}
+			}
+		})
+	}
+}
+
+type CommonLabels struct {
+	Service string `label:"service"`
+	Region  string `label:"region,default=unknown"`
+}
+
+type TenantLabels struct {
+	CommonLabels
+	Tenant string `label:"tenant"`
+}
+
+func TestEmbeddedLabels(t *testing.T) {
+	type requestLabels struct {
+		TenantLabels
+		Status int    `label:"status"`
+		Debug  string `label:"-"`
+		cache  string `label:"-"`
+	}
+
+	keys := getLabelKeys[*requestLabels]()
+
+	expectedKeys := []string{"service", "region", "tenant", "status"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected keys %v, got %v", expectedKeys, keys)
+	}
+
+	labels := &requestLabels{Status: 200, Debug: "ignored"}
+	labels.Service = "api"
+	labels.Tenant = "acme"
+
+	result := getLabelValues(labels)
+
+	expected := map[string]string{
+		"service": "api",
+		"region":  "unknown",
+		"tenant":  "acme",
+		"status":  "200",
+	}
+	if !reflect.DeepEqual(map[string]string(result), expected) {
+		t.Errorf("Expected labels %v, got %v", expected, result)
+	}
+}
+
 func TestNilReflectValue(t *testing.T) {
 	// Test what happens when we call String() on a nil reflect.Value
 	var v reflect.Value
@@ -234,6 +394,103 @@ func TestUnsupportedFieldTypes(t *testing.T) {
 				getLabelKeys[testStruct]()
 			},
 		},
+		{
+			name: "float field",
+			fn: func() {
+				type testStruct struct {
+					Name  string  `label:"name"`
+					Ratio float64 `label:"ratio"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "stringer interface field",
+			fn: func() {
+				type testStruct struct {
+					Name  string       `label:"name"`
+					State fmt.Stringer `label:"state"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "unknown tag option",
+			fn: func() {
+				type testStruct struct {
+					Name string `label:"name,unknown=1"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "empty values tag option",
+			fn: func() {
+				type testStruct struct {
+					Name string `label:"name,values="`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "fallback without values tag option",
+			fn: func() {
+				type testStruct struct {
+					Name string `label:"name,fallback=other"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "invalid maxlen tag option",
+			fn: func() {
+				type testStruct struct {
+					Name string `label:"name,maxlen=0"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "duplicate label",
+			fn: func() {
+				type testStruct struct {
+					Name  string `label:"name"`
+					Other string `label:"name"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "duplicate label in embedded struct",
+			fn: func() {
+				type testStruct struct {
+					CommonLabels
+					Region string `label:"region"`
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "unexported embedded struct",
+			fn: func() {
+				type embedded struct {
+					Name string `label:"name"`
+				}
+				type testStruct struct {
+					embedded
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
+		{
+			name: "embedded pointer struct",
+			fn: func() {
+				type testStruct struct {
+					*CommonLabels
+				}
+				getLabelKeys[testStruct]()
+			},
+		},
 		{
 			name: "unexported field",
 			fn: func() {
@@ -0,0 +1,98 @@
+package metricstest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/metrics"
+	"github.com/prometheus/common/expfmt"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write the golden files
+// instead of comparing against them, e.g.:
+//
+//	METRICSTEST_UPDATE=1 go test ./...
+const UpdateGoldenEnv = "METRICSTEST_UPDATE"
+
+// AssertGolden compares the metrics of the registry in the Prometheus text exposition format
+// against the golden file, and reports the differences. If names are given, only these metrics
+// are compared, e.g. to leave out metrics with nondeterministic values such as durations.
+// Set the METRICSTEST_UPDATE environment variable to create or update the golden file.
+func AssertGolden(t testing.TB, reg *metrics.Registry, golden string, names ...string) {
+	t.Helper()
+
+	got := Exposition(t, reg, names...)
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("metricstest: %v", err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatalf("metricstest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("metricstest: %v (run with %s=1 to create the golden file)", err, UpdateGoldenEnv)
+	}
+	if diff := diffLines(string(want), got); diff != "" {
+		t.Errorf("metricstest: metrics differ from %s (-want +got):\n%s\nrun with %s=1 to update the golden file", golden, diff, UpdateGoldenEnv)
+	}
+}
+
+// Exposition returns the metrics of the registry in the Prometheus text exposition format.
+// If names are given, only these metrics are returned.
+func Exposition(t testing.TB, reg *metrics.Registry, names ...string) string {
+	t.Helper()
+
+	mfs, err := reg.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("metricstest: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if len(names) > 0 && !slices.Contains(names, mf.GetName()) {
+			continue
+		}
+		if err := enc.Encode(mf); err != nil {
+			t.Fatalf("metricstest: %v", err)
+		}
+	}
+	return buf.String()
+}
+
+// diffLines returns the lines missing from got (-) and the unexpected lines in got (+),
+// or an empty string if want and got are equal.
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var diff strings.Builder
+	for _, line := range wantLines {
+		if !slices.Contains(gotLines, line) {
+			fmt.Fprintf(&diff, "- %s\n", line)
+		}
+	}
+	for _, line := range gotLines {
+		if !slices.Contains(wantLines, line) {
+			fmt.Fprintf(&diff, "+ %s\n", line)
+		}
+	}
+	if diff.Len() == 0 {
+		return "(metrics are in a different order)\n"
+	}
+	return diff.String()
+}
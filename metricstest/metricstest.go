@@ -0,0 +1,145 @@
+// Package metricstest provides helpers for asserting metric values in unit tests, without scraping
+// and parsing the output of metrics.Handler.
+//
+// Create the metrics under test in an isolated registry, so that tests don't share state:
+//
+//	reg := metricstest.NewRegistry()
+//	jobs := metrics.CounterWith[jobLabels]("jobs_processed_total", "Number of jobs processed", metrics.WithRegistry(reg))
+//
+//	processJobs(jobs)
+//
+//	if got := metricstest.CounterValue(t, &jobs, jobLabels{Name: "sync", Status: "ok"}); got != 3 {
+//		t.Errorf("expected 3 processed jobs, got %v", got)
+//	}
+//
+// The built-in Collector and Transport metrics can be asserted by name, after driving requests
+// through httptest:
+//
+//	r.Use(metrics.Collector(metrics.CollectorOpts{Registry: reg}))
+//	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+//
+//	metricstest.Value(t, reg, "http_requests_total", map[string]string{"endpoint": "GET /users/{id}", "status": "200"})
+package metricstest
+
+import (
+	"testing"
+
+	"github.com/go-chi/metrics"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// NewRegistry returns a new registry isolated from other tests and from metrics.DefaultRegistry.
+// Pass it to the metrics under test with the metrics.WithRegistry option, and to Collector and
+// Transport with their Registry option.
+func NewRegistry() *metrics.Registry {
+	return metrics.NewRegistry()
+}
+
+// CounterValue returns the value of the counter series with the given labels, or 0 if the series doesn't exist.
+// It reads the series like CounterMetricLabeled.Value.
+func CounterValue[T any](t testing.TB, c *metrics.CounterMetricLabeled[T], labels T) float64 {
+	t.Helper()
+	return c.Value(labels)
+}
+
+// GaugeValue returns the value of the gauge series with the given labels, or 0 if the series doesn't exist.
+// It reads the series like GaugeMetricLabeled.Value.
+func GaugeValue[T any](t testing.TB, g *metrics.GaugeMetricLabeled[T], labels T) float64 {
+	t.Helper()
+	return g.Value(labels)
+}
+
+// HistogramCount returns the number of observations of the histogram series with the given labels,
+// or 0 if the series doesn't exist. It reads the series like HistogramMetricLabeled.Snapshot.
+func HistogramCount[T any](t testing.TB, h *metrics.HistogramMetricLabeled[T], labels T) uint64 {
+	t.Helper()
+	return h.Snapshot(labels).Count
+}
+
+// HistogramSum returns the sum of observations of the histogram series with the given labels,
+// or 0 if the series doesn't exist. It reads the series like HistogramMetricLabeled.Snapshot.
+func HistogramSum[T any](t testing.TB, h *metrics.HistogramMetricLabeled[T], labels T) float64 {
+	t.Helper()
+	return h.Snapshot(labels).Sum
+}
+
+// Value returns the sum of the values of all counter or gauge series of the named metric in the
+// registry that match the given labels, e.g. of the built-in http_requests_total metric for an endpoint.
+// Labels not given match any value. It returns 0 if no series match.
+func Value(t testing.TB, reg *metrics.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	var sum float64
+	for _, m := range gather(t, reg, name, labels) {
+		switch {
+		case m.Counter != nil:
+			sum += m.GetCounter().GetValue()
+		case m.Gauge != nil:
+			sum += m.GetGauge().GetValue()
+		case m.Untyped != nil:
+			sum += m.GetUntyped().GetValue()
+		default:
+			t.Fatalf("metricstest: metric %s is not a counter or gauge", name)
+		}
+	}
+	return sum
+}
+
+// Count returns the total number of observations of all histogram or summary series of the named metric
+// in the registry that match the given labels, e.g. of the built-in http_request_duration_seconds metric.
+// Labels not given match any value. It returns 0 if no series match.
+func Count(t testing.TB, reg *metrics.Registry, name string, labels map[string]string) uint64 {
+	t.Helper()
+
+	var count uint64
+	for _, m := range gather(t, reg, name, labels) {
+		switch {
+		case m.Histogram != nil:
+			count += m.GetHistogram().GetSampleCount()
+		case m.Summary != nil:
+			count += m.GetSummary().GetSampleCount()
+		default:
+			t.Fatalf("metricstest: metric %s is not a histogram or summary", name)
+		}
+	}
+	return count
+}
+
+// gather returns the series of the named metric in the registry that match the given labels.
+func gather(t testing.TB, reg *metrics.Registry, name string, labels map[string]string) []*dto.Metric {
+	t.Helper()
+
+	mfs, err := reg.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("metricstest: %v", err)
+	}
+
+	var series []*dto.Metric
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if matches(m, labels) {
+				series = append(series, m)
+			}
+		}
+	}
+	return series
+}
+
+// matches reports whether the series has all the given labels.
+func matches(m *dto.Metric, labels map[string]string) bool {
+	found := 0
+	for _, lp := range m.GetLabel() {
+		value, ok := labels[lp.GetName()]
+		if !ok {
+			continue
+		}
+		if value != lp.GetValue() {
+			return false
+		}
+		found++
+	}
+	return found == len(labels)
+}
@@ -0,0 +1,144 @@
+package metricstest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/metrics"
+)
+
+type jobLabels struct {
+	Name   string `label:"name"`
+	Status string `label:"status"`
+}
+
+func TestTypedAssertions(t *testing.T) {
+	reg := NewRegistry()
+	jobs := metrics.CounterWith[jobLabels]("jobs_processed_total", "Number of jobs processed.", metrics.WithRegistry(reg))
+	queued := metrics.GaugeWith[jobLabels]("jobs_queued", "Number of queued jobs.", metrics.WithRegistry(reg))
+	duration := metrics.HistogramWith[jobLabels]("job_duration_seconds", "Job duration.", []float64{1, 5}, metrics.WithRegistry(reg))
+
+	jobs.Inc(jobLabels{Name: "sync", Status: "ok"})
+	jobs.Add(2, jobLabels{Name: "sync", Status: "ok"})
+	jobs.Inc(jobLabels{Name: "sync", Status: "error"})
+	queued.Set(4, jobLabels{Name: "sync"})
+	duration.Observe(0.5, jobLabels{Name: "sync", Status: "ok"})
+	duration.Observe(2, jobLabels{Name: "sync", Status: "ok"})
+
+	if got := CounterValue(t, &jobs, jobLabels{Name: "sync", Status: "ok"}); got != 3 {
+		t.Errorf("expected counter value 3, got %v", got)
+	}
+	if got := CounterValue(t, &jobs, jobLabels{Name: "sync", Status: "timeout"}); got != 0 {
+		t.Errorf("expected counter value 0 for a missing series, got %v", got)
+	}
+	if got := GaugeValue(t, &queued, jobLabels{Name: "sync"}); got != 4 {
+		t.Errorf("expected gauge value 4, got %v", got)
+	}
+	if got := HistogramCount(t, &duration, jobLabels{Name: "sync", Status: "ok"}); got != 2 {
+		t.Errorf("expected histogram count 2, got %v", got)
+	}
+	if got := HistogramSum(t, &duration, jobLabels{Name: "sync", Status: "ok"}); got != 2.5 {
+		t.Errorf("expected histogram sum 2.5, got %v", got)
+	}
+	if got := Value(t, reg, "jobs_processed_total", map[string]string{"name": "sync"}); got != 4 {
+		t.Errorf("expected 4 processed jobs in total, got %v", got)
+	}
+
+	// Registries are isolated, so the same metric can be created in another test.
+	other := metrics.CounterWith[jobLabels]("jobs_processed_total", "Number of jobs processed.", metrics.WithRegistry(NewRegistry()))
+	if got := CounterValue(t, &other, jobLabels{Name: "sync", Status: "ok"}); got != 0 {
+		t.Errorf("expected counter value 0 in another registry, got %v", got)
+	}
+}
+
+func TestTypedAssertionsFallback(t *testing.T) {
+	type statusLabels struct {
+		Status string `label:"status,values=ok|error"`
+	}
+
+	reg := NewRegistry()
+	c := metrics.CounterWith[statusLabels]("results_total", "Results.", metrics.WithRegistry(reg))
+	c.Inc(statusLabels{Status: "crashed"})
+
+	// Values that are not allowed are read from the fallback series, like they're recorded.
+	if got, want := CounterValue(t, &c, statusLabels{Status: "crashed"}), c.Value(statusLabels{Status: "crashed"}); got != 1 || got != want {
+		t.Errorf("expected counter value 1 like Value (%v), got %v", want, got)
+	}
+	if got := CounterValue(t, &c, statusLabels{Status: "other"}); got != 1 {
+		t.Errorf("expected counter value 1 of the fallback series, got %v", got)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	reg := NewRegistry()
+
+	r := chi.NewRouter()
+	r.Use(metrics.Collector(metrics.CollectorOpts{Registry: reg}))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+	if got := Value(t, reg, "http_requests_total", map[string]string{"endpoint": "GET /users/{id}", "status": "200"}); got != 2 {
+		t.Errorf("expected 2 GET requests, got %v", got)
+	}
+	if got := Value(t, reg, "http_requests_total", nil); got != 3 {
+		t.Errorf("expected 3 requests in total, got %v", got)
+	}
+	if got := Count(t, reg, "http_request_duration_seconds", map[string]string{"endpoint": "POST /users"}); got != 1 {
+		t.Errorf("expected 1 observed POST request, got %v", got)
+	}
+
+	// Durations vary between runs, so only the deterministic metrics are compared.
+	AssertGolden(t, reg, "testdata/collector.golden", "http_requests_total", "http_requests_inflight")
+}
+
+func TestTransport(t *testing.T) {
+	reg := NewRegistry()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: metrics.Transport(metrics.TransportOpts{Registry: reg})(server.Client().Transport),
+	}
+	for _, path := range []string{"/", "/", "/missing"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := Value(t, reg, "http_client_requests_total", map[string]string{"status": "200"}); got != 2 {
+		t.Errorf("expected 2 successful requests, got %v", got)
+	}
+	if got := Value(t, reg, "http_client_requests_total", map[string]string{"status": "404"}); got != 1 {
+		t.Errorf("expected 1 not found request, got %v", got)
+	}
+	if got := Count(t, reg, "http_client_request_duration_seconds", nil); got != 3 {
+		t.Errorf("expected 3 observed requests, got %v", got)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	want := "a 1\nb 2\n"
+	if diff := diffLines(want, want); diff != "" {
+		t.Errorf("expected no diff, got:\n%s", diff)
+	}
+	diff := diffLines(want, "a 1\nb 3\n")
+	if !strings.Contains(diff, "- b 2") || !strings.Contains(diff, "+ b 3") || strings.Contains(diff, "a 1") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// NameValidationScheme determines which metric and label names are valid, see SetNameValidation.
+// Label names starting with "__" are reserved for internal use by Prometheus in all schemes.
+type NameValidationScheme uint8
+
+const (
+	// DefaultNameValidation follows the Prometheus data model: metric names must match
+	// [a-zA-Z_:][a-zA-Z0-9_:]* and label names must match [a-zA-Z_][a-zA-Z0-9_]*.
+	// Colons are reserved for recording rules by convention, but are accepted.
+	DefaultNameValidation NameValidationScheme = iota
+
+	// LowercaseNameValidation only accepts lowercase metric and label names matching [a-z_][a-z0-9_]*.
+	LowercaseNameValidation
+
+	// UTF8NameValidation accepts any metric and label names that are valid UTF-8, as supported
+	// by Prometheus 3.0 and later. Names are escaped for scrapers that don't support UTF-8 names.
+	UTF8NameValidation
+)
+
+// nameValidation holds the scheme set by SetNameValidation.
+var nameValidation atomic.Uint32
+
+// SetNameValidation sets the scheme used to validate metric and label names, including the names
+// in label struct tags, constant labels, global labels, namespaces and subsystems.
+// Names are validated when metrics are created, so call SetNameValidation before any metrics are defined.
+func SetNameValidation(scheme NameValidationScheme) {
+	nameValidation.Store(uint32(scheme))
+}
+
+func loadNameValidation() NameValidationScheme {
+	return NameValidationScheme(nameValidation.Load())
+}
+
+// isValidMetricName checks if a metric name is valid in the current name validation scheme.
+func isValidMetricName(s string) bool {
+	switch loadNameValidation() {
+	case LowercaseNameValidation:
+		return isLowercaseName(s)
+	case UTF8NameValidation:
+		return s != "" && utf8.ValidString(s)
+	}
+
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && c != ':' && !isASCIILetter(c) && (i == 0 || !isASCIIDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidLabelName checks if a label name is valid in the current name validation scheme
+// and isn't reserved by Prometheus.
+func isValidLabelName(s string) bool {
+	if strings.HasPrefix(s, "__") {
+		return false
+	}
+
+	switch loadNameValidation() {
+	case LowercaseNameValidation:
+		return isLowercaseName(s)
+	case UTF8NameValidation:
+		return s != "" && utf8.ValidString(s)
+	}
+
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && !isASCIILetter(c) && (i == 0 || !isASCIIDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLowercaseName checks if a name matches [a-z_][a-z0-9_]*.
+func isLowercaseName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && (c < 'a' || c > 'z') && (i == 0 || !isASCIIDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// metricNameRule describes valid metric names for error messages.
+func (s NameValidationScheme) metricNameRule() string {
+	switch s {
+	case LowercaseNameValidation:
+		return "must match [a-z_][a-z0-9_]*"
+	case UTF8NameValidation:
+		return "must be non-empty valid UTF-8"
+	}
+	return "must match [a-zA-Z_:][a-zA-Z0-9_:]*"
+}
+
+// labelNameRule describes valid label names for error messages.
+func (s NameValidationScheme) labelNameRule() string {
+	switch s {
+	case LowercaseNameValidation:
+		return "must match [a-z_][a-z0-9_]* and not start with __"
+	case UTF8NameValidation:
+		return "must be non-empty valid UTF-8 and not start with __"
+	}
+	return "must match [a-zA-Z_][a-zA-Z0-9_]* and not start with __"
+}
+
+// validMetricName validates a metric name and returns an error if it doesn't match the required Prometheus format.
+func validMetricName(s string) error {
+	if !isValidMetricName(s) {
+		return errors.New("invalid metric name: " + s + " (" + loadNameValidation().metricNameRule() + ")")
+	}
+	return nil
+}
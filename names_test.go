@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNameValidation(t *testing.T) {
+	t.Cleanup(func() { SetNameValidation(DefaultNameValidation) })
+
+	tt := []struct {
+		name      string
+		metric    bool
+		valid     bool
+		lowercase bool
+		utf8      bool
+	}{
+		{name: "http_requests_total", metric: true, valid: true, lowercase: true, utf8: true},
+		{name: "job:http_requests:rate5m", metric: true, valid: true, lowercase: false, utf8: true},
+		{name: "HTTPRequests", metric: true, valid: true, lowercase: false, utf8: true},
+		{name: "_private", metric: true, valid: true, lowercase: true, utf8: true},
+		{name: "1xx_responses", metric: true, valid: false, lowercase: false, utf8: true},
+		{name: "http.requests", metric: true, valid: false, lowercase: false, utf8: true},
+		{name: "", metric: true, valid: false, lowercase: false, utf8: false},
+		{name: "status_code", valid: true, lowercase: true, utf8: true},
+		{name: "statusCode", valid: true, lowercase: false, utf8: true},
+		{name: "status:code", valid: false, lowercase: false, utf8: true},
+		{name: "__name__", valid: false, lowercase: false, utf8: false},
+		{name: "__meta", valid: false, lowercase: false, utf8: false},
+		{name: "région", valid: false, lowercase: false, utf8: true},
+		{name: "\xff", valid: false, lowercase: false, utf8: false},
+	}
+
+	for _, scheme := range []NameValidationScheme{DefaultNameValidation, LowercaseNameValidation, UTF8NameValidation} {
+		SetNameValidation(scheme)
+		for _, tc := range tt {
+			want := tc.valid
+			switch scheme {
+			case LowercaseNameValidation:
+				want = tc.lowercase
+			case UTF8NameValidation:
+				want = tc.utf8
+			}
+
+			got := isValidLabelName(tc.name)
+			if tc.metric {
+				got = isValidMetricName(tc.name)
+			}
+			if got != want {
+				t.Errorf("scheme %d: %q valid = %v, want %v", scheme, tc.name, got, want)
+			}
+		}
+	}
+}
+
+func TestNameValidationMetrics(t *testing.T) {
+	type labels struct {
+		StatusCode string `label:"statusCode"`
+	}
+	type reservedLabels struct {
+		Name string `label:"__name"`
+	}
+
+	reg := NewRegistry()
+	if _, err := NewCounterWith[labels]("job:requests:total", "Requests.", WithRegistry(reg)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewCounterWith[reservedLabels]("reserved_total", "Reserved.", WithRegistry(reg)); err == nil || !strings.Contains(err.Error(), "not start with __") {
+		t.Errorf("expected error for reserved label name, got %v", err)
+	}
+	if _, err := NewCounter("requests_total", "Requests.", WithRegistry(reg), WithConstLabels(map[string]string{"__app": "api"})); err == nil {
+		t.Error("expected error for reserved constant label name")
+	}
+
+	SetNameValidation(LowercaseNameValidation)
+	t.Cleanup(func() { SetNameValidation(DefaultNameValidation) })
+
+	if _, err := NewCounter("Requests_total", "Requests.", WithRegistry(reg)); err == nil || !strings.Contains(err.Error(), "[a-z_][a-z0-9_]*") {
+		t.Errorf("expected error for uppercase metric name, got %v", err)
+	}
+
+	SetNameValidation(UTF8NameValidation)
+
+	c := Counter("requests.total", "Requests.", WithRegistry(reg), WithConstLabels(map[string]string{"région": "eu"}))
+	c.Inc()
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, "requests_total") {
+		t.Errorf("expected escaped UTF-8 metric name in output:\n%s", body)
+	}
+}
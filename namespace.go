@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namePrefix holds the namespace and subsystem prepended to metric names.
+type namePrefix struct {
+	namespace string
+	subsystem string
+}
+
+// globalPrefix holds the prefix set by SetNamespace.
+var globalPrefix atomic.Pointer[namePrefix]
+
+// SetNamespace sets the namespace and subsystem prepended to the names of all metrics created
+// afterwards, e.g. "myapp" and "api" turn http_requests_total into myapp_api_http_requests_total.
+// Either may be empty. It applies to all registries, unless overridden by the WithNamespace
+// or WithSubsystem registry options, and to the built-in Collector and Transport metrics.
+//
+// Metric names are fixed once a metric is created, so call SetNamespace before any metrics
+// are defined, e.g. in an init function of a package imported before the instrumented ones,
+// or use a registry created with the WithNamespace option instead.
+// It panics if the namespace or subsystem is invalid.
+func SetNamespace(namespace, subsystem string) {
+	if err := validNamePrefix(namespace, subsystem); err != nil {
+		panic(err)
+	}
+	globalPrefix.Store(&namePrefix{namespace: namespace, subsystem: subsystem})
+}
+
+// RegistryOption configures a registry created by NewRegistry.
+type RegistryOption func(*Registry)
+
+// WithNamespace sets the namespace prepended to the names of all metrics in the registry,
+// overriding the namespace set by SetNamespace.
+func WithNamespace(namespace string) RegistryOption {
+	return func(r *Registry) {
+		r.prefix.namespace = namespace
+	}
+}
+
+// WithSubsystem sets the subsystem prepended to the names of all metrics in the registry,
+// after the namespace, overriding the subsystem set by SetNamespace.
+func WithSubsystem(subsystem string) RegistryOption {
+	return func(r *Registry) {
+		r.prefix.subsystem = subsystem
+	}
+}
+
+// validNamePrefix checks the namespace and subsystem of metric names.
+func validNamePrefix(namespace, subsystem string) error {
+	if namespace != "" && !isValidMetricName(namespace) {
+		return fmt.Errorf("invalid metric namespace: %s (%s)", namespace, loadNameValidation().metricNameRule())
+	}
+	if subsystem != "" && !isValidMetricName(subsystem) {
+		return fmt.Errorf("invalid metric subsystem: %s (%s)", subsystem, loadNameValidation().metricNameRule())
+	}
+	return nil
+}
+
+// fqName returns the metric name prefixed with the namespace and subsystem of the registry,
+// falling back to the ones set by SetNamespace.
+func (r *Registry) fqName(name string) string {
+	prefix := r.prefix
+	if global := globalPrefix.Load(); global != nil {
+		if prefix.namespace == "" {
+			prefix.namespace = global.namespace
+		}
+		if prefix.subsystem == "" {
+			prefix.subsystem = global.subsystem
+		}
+	}
+	return prometheus.BuildFQName(prefix.namespace, prefix.subsystem, name)
+}
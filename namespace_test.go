@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryNamespace(t *testing.T) {
+	reg := NewRegistry(WithNamespace("myapp"), WithSubsystem("api"))
+
+	c := Counter("jobs_total", "Jobs.", WithRegistry(reg))
+	c.Inc()
+
+	handler := Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		"myapp_api_jobs_total 1",
+		"myapp_api_http_requests_total{",
+		"myapp_api_http_requests_inflight{",
+		"myapp_api_http_request_duration_seconds_bucket{",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
+
+func TestGlobalNamespace(t *testing.T) {
+	SetNamespace("myapp", "worker")
+	t.Cleanup(func() { SetNamespace("", "") })
+
+	global := NewRegistry()
+	Counter("jobs_total", "Jobs.", WithRegistry(global))
+	if body := scrape(t, global.Handler()); !strings.Contains(body, "myapp_worker_jobs_total 0") {
+		t.Errorf("expected myapp_worker_jobs_total in output:\n%s", body)
+	}
+
+	// Registry options override the package-level namespace and subsystem individually.
+	reg := NewRegistry(WithSubsystem("api"))
+	c := Counter("jobs_total", "Jobs.", WithRegistry(reg))
+	c.Inc()
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, "myapp_api_jobs_total 1") {
+		t.Errorf("expected myapp_api_jobs_total in output:\n%s", body)
+	}
+}
+
+func TestNamespaceValidation(t *testing.T) {
+	for _, opt := range []RegistryOption{WithNamespace("my-app"), WithSubsystem("1api")} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic for invalid registry prefix")
+				}
+			}()
+			NewRegistry(opt)
+		}()
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for invalid namespace")
+			}
+		}()
+		SetNamespace("my app", "")
+	}()
+
+	// The combined name is validated too.
+	reg := NewRegistry(WithNamespace("myapp"))
+	if _, err := NewCounter("jobs-total", "Jobs.", WithRegistry(reg)); err == nil {
+		t.Error("expected error for invalid metric name")
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// strictNaming is set by SetStrictNaming.
+var strictNaming atomic.Bool
+
+var (
+	// baseUnits are the units recommended by the Prometheus naming conventions.
+	baseUnits = []string{"seconds", "bytes", "ratio", "meters", "grams", "celsius", "volts", "amperes", "joules"}
+
+	// nonBaseUnits maps common units to the base unit that should be used instead.
+	nonBaseUnits = map[string]string{
+		"nanoseconds":  "seconds",
+		"microseconds": "seconds",
+		"milliseconds": "seconds",
+		"minutes":      "seconds",
+		"hours":        "seconds",
+		"days":         "seconds",
+		"kilobytes":    "bytes",
+		"megabytes":    "bytes",
+		"gigabytes":    "bytes",
+		"percent":      "ratio",
+	}
+)
+
+// WithUnit sets the unit of the metric, e.g. "seconds" or "bytes", which is served as # UNIT metadata
+// in the OpenMetrics exposition format. As required by OpenMetrics, the metric name must end with
+// the unit, followed by _total for counters, e.g. "request_duration_seconds" or "sent_bytes_total".
+func WithUnit(unit string) Option {
+	return func(o *metricOpts) {
+		o.unit = unit
+	}
+}
+
+// SetStrictNaming enables or disables the enforcement of the Prometheus naming conventions
+// for metrics created afterwards in all registries, see WithStrictNaming.
+func SetStrictNaming(strict bool) {
+	strictNaming.Store(strict)
+}
+
+// WithStrictNaming enforces the Prometheus naming conventions for all metrics in the registry:
+// counter names must end with _total and other metric names must not, names must use base units
+// (e.g. seconds instead of milliseconds), and names ending with a base unit must declare it
+// with the WithUnit option. Metrics violating the conventions fail to be created.
+func WithStrictNaming() RegistryOption {
+	return func(r *Registry) {
+		r.strict = true
+	}
+}
+
+// validNaming checks the unit of the metric and, in strict mode, the naming conventions.
+func validNaming(typ dto.MetricType, name, unit string, strict bool) error {
+	base := name
+	if typ == dto.MetricType_COUNTER {
+		base = strings.TrimSuffix(name, "_total")
+	}
+
+	if unit != "" {
+		if !isValidLabelName(unit) {
+			return fmt.Errorf("invalid unit of metric %s: %q (%s)", name, unit, loadNameValidation().labelNameRule())
+		}
+		if !strings.HasSuffix(base, "_"+unit) {
+			return fmt.Errorf("metric name %s doesn't match its unit %q: rename it to %s", name, unit, withSuffix(typ, base+"_"+unit))
+		}
+	}
+
+	if !strict {
+		return nil
+	}
+
+	typeName := strings.ToLower(typ.String())
+	if typ == dto.MetricType_COUNTER && base == name {
+		return fmt.Errorf("invalid counter name %s: counter names must end with _total, rename it to %s_total", name, name)
+	}
+	if typ != dto.MetricType_COUNTER && strings.HasSuffix(name, "_total") {
+		return fmt.Errorf("invalid %s name %s: only counter names may end with _total, rename it to %s", typeName, name, strings.TrimSuffix(name, "_total"))
+	}
+
+	suffix := base[strings.LastIndexByte(base, '_')+1:]
+	if baseUnit, ok := nonBaseUnits[suffix]; ok {
+		return fmt.Errorf("invalid %s name %s: use the base unit %q instead of %q, rename it to %s and convert its values", typeName, name, baseUnit, suffix, withSuffix(typ, strings.TrimSuffix(base, suffix)+baseUnit))
+	}
+	if unit == "" && slices.Contains(baseUnits, suffix) {
+		return fmt.Errorf("%s %s has no unit: declare it with the WithUnit(%q) option", typeName, name, suffix)
+	}
+	return nil
+}
+
+// withSuffix adds the _total suffix to counter names.
+func withSuffix(typ dto.MetricType, name string) string {
+	if typ == dto.MetricType_COUNTER {
+		return name + "_total"
+	}
+	return name
+}
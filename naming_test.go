@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUnit(t *testing.T) {
+	reg := NewRegistry()
+	Histogram("job_duration_seconds", "Job duration.", []float64{1}, WithRegistry(reg), WithUnit("seconds"))
+	Counter("sent_bytes_total", "Sent bytes.", WithRegistry(reg), WithUnit("bytes"))
+
+	handler := Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	body := scrapeOpenMetrics(t, reg.Handler())
+	for _, want := range []string{
+		"# UNIT job_duration_seconds seconds",
+		"# UNIT sent_bytes bytes",
+		"# UNIT http_request_duration_seconds seconds",
+		"# EOF",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+
+	if _, err := NewGauge("queue_size", "Queue size.", WithRegistry(reg), WithUnit("bytes")); err == nil || !strings.Contains(err.Error(), "queue_size_bytes") {
+		t.Errorf("expected error suggesting queue_size_bytes, got %v", err)
+	}
+	if _, err := NewCounter("sent_total", "Sent.", WithRegistry(reg), WithUnit("bytes")); err == nil || !strings.Contains(err.Error(), "sent_bytes_total") {
+		t.Errorf("expected error suggesting sent_bytes_total, got %v", err)
+	}
+	if _, err := NewGauge("queue_size_bytes", "Queue size.", WithRegistry(reg), WithUnit("by-tes")); err == nil {
+		t.Error("expected error for invalid unit")
+	}
+}
+
+func TestStrictNaming(t *testing.T) {
+	reg := NewRegistry(WithStrictNaming())
+
+	tt := []struct {
+		name    string
+		create  func(name string) error
+		metric  string
+		wantErr string
+	}{
+		{"counter", newCounter(reg), "jobs_processed_total", ""},
+		{"counter without _total", newCounter(reg), "jobs_processed", "rename it to jobs_processed_total"},
+		{"gauge with _total", newGauge(reg), "queue_total", "rename it to queue"},
+		{"non-base unit", newGauge(reg), "latency_milliseconds", "rename it to latency_seconds"},
+		{"missing unit", newGauge(reg), "latency_seconds", `WithUnit("seconds")`},
+		{"with unit", newGauge(reg, WithUnit("seconds")), "uptime_seconds", ""},
+		{"counter with unit", newCounter(reg, WithUnit("bytes")), "sent_bytes_total", ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.create(tc.metric)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	// Built-in metrics follow the naming conventions, or Collector and Transport would panic.
+	Collector(CollectorOpts{Registry: reg})
+	Transport(TransportOpts{Registry: reg})
+}
+
+func newCounter(reg *Registry, opts ...Option) func(name string) error {
+	return func(name string) error {
+		_, err := NewCounter(name, "Help.", append(opts, WithRegistry(reg))...)
+		return err
+	}
+}
+
+func newGauge(reg *Registry, opts ...Option) func(name string) error {
+	return func(name string) error {
+		_, err := NewGauge(name, "Help.", append(opts, WithRegistry(reg))...)
+		return err
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Option configures a metric created by one of the constructors, e.g. Counter or HistogramWith.
+type Option func(*metricOpts)
+
+// metricOpts holds the configuration shared by all metric types.
+type metricOpts struct {
+	registry *Registry
+	reuse    bool
+	limit    int
+	ttl      time.Duration
+
+	constLabels map[string]string
+	unit        string
+
+	// openMetricsType is set by constructors of metric types that Prometheus doesn't support.
+	openMetricsType string
+
+	labelValuePolicy LabelValuePolicy
+
+	// Histogram options.
+	native *NativeHistogramOpts
+
+	// Summary options.
+	maxAge     time.Duration
+	ageBuckets uint32
+}
+
+func newMetricOpts(opts []Option) metricOpts {
+	var o metricOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.registry = o.registry.orDefault()
+	return o
+}
+
+// WithRegistry creates the metric in the given registry instead of DefaultRegistry.
+func WithRegistry(r *Registry) Option {
+	return func(o *metricOpts) {
+		o.registry = r
+	}
+}
+
+// WithReuse makes the constructor return the already registered metric instead of failing,
+// if a metric with the same name, type, help and label keys exists in the registry.
+// This is useful for table-driven tests, plugin reloads and libraries that define metrics lazily.
+func WithReuse() Option {
+	return func(o *metricOpts) {
+		o.reuse = true
+	}
+}
+
+// metadata returns the metadata of the metric that isn't part of its gathered metric family.
+func (o *metricOpts) metadata() metricMetadata {
+	return metricMetadata{unit: o.unit, openMetricsType: o.openMetricsType}
+}
+
+// validate checks the metric name and the options that depend on the type or label keys of the metric.
+func (o *metricOpts) validate(typ dto.MetricType, name string, keys []string) error {
+	if err := validMetricName(name); err != nil {
+		return err
+	}
+	if err := validNaming(typ, name, o.unit, o.registry.strict || strictNaming.Load()); err != nil {
+		return err
+	}
+	return validConstLabelKeys(o.constLabels, keys)
+}
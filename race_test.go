@@ -0,0 +1,7 @@
+//go:build race
+
+package metrics
+
+// raceEnabled reports whether the race detector is enabled, which makes sync.Pool
+// drop items at random and thus allocation counts unreliable.
+const raceEnabled = true
@@ -0,0 +1,221 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultRegistry is the registry used by all metrics created without the WithRegistry option,
+// and by Collector and Transport unless configured otherwise. It wraps the Prometheus default
+// registerer and gatherer, so it also serves the Go runtime and process metrics.
+var DefaultRegistry = &Registry{
+	registerer: prometheus.DefaultRegisterer,
+	gatherer:   prometheus.DefaultGatherer,
+}
+
+// Registry is an isolated set of metrics with its own Handler. It lets separate components,
+// tests or tenants own their metrics without sharing global state or running into
+// duplicate registration panics.
+//
+// Metrics are created in a registry by passing the WithRegistry option to any constructor:
+//
+//	reg := metrics.NewRegistry()
+//	jobs := metrics.CounterWith[jobLabels]("jobs_processed_total", "Number of jobs processed", metrics.WithRegistry(reg))
+//	r.Handle("/metrics", reg.Handler())
+type Registry struct {
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+
+	// Namespace and subsystem prepended to metric names, see WithNamespace.
+	prefix namePrefix
+
+	// Naming conventions are enforced, see WithStrictNaming.
+	strict bool
+
+	// Logger of errors while serving metrics, see WithErrorLog.
+	errorLog promhttp.Logger
+
+	// Metadata of the metrics that isn't part of the gathered metric families.
+	metadata sync.Map // map[string]metricMetadata
+
+	// Built-in HTTP metrics, created lazily by Collector and Transport.
+	serverOnce    sync.Once
+	serverMetrics *serverMetrics
+	clientOnce    sync.Once
+	clientMetrics *clientMetrics
+
+	// Counter of observations folded into overflow series due to series limits, created on first use.
+	overflowOnce sync.Once
+	overflow     CounterMetricLabeled[overflowLabels]
+
+	// Counter of observations rejected due to the `values` tag option, created on first use.
+	rejectedOnce sync.Once
+	rejected     CounterMetricLabeled[rejectedLabels]
+
+	// Series trackers of labeled metrics, shared by reused metrics.
+	trackers sync.Map // map[*prometheus.MetricVec]*seriesTracker
+}
+
+// NewRegistry creates a new empty registry. It panics if the namespace or subsystem
+// set by the WithNamespace or WithSubsystem options is invalid.
+func NewRegistry(opts ...RegistryOption) *Registry {
+	reg := prometheus.NewRegistry()
+	r := &Registry{
+		registerer: reg,
+		gatherer:   reg,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := validNamePrefix(r.prefix.namespace, r.prefix.subsystem); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Handler returns an HTTP handler that serves Prometheus metrics from the registry
+// in the OpenMetrics exposition format, or the Prometheus text format for scrapers that
+// don't support OpenMetrics.
+func (r *Registry) Handler() http.Handler {
+	return newHandler(r)
+}
+
+// Gatherer returns the Prometheus gatherer of the registry, e.g. for use with promhttp or testutil.
+// It adds the units of the metrics and the labels set by SetGlobalLabels to all gathered metrics.
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	return prometheus.GathererFunc(r.gather)
+}
+
+func (r *Registry) gather() ([]*dto.MetricFamily, error) {
+	// Delete series that expired due to the WithTTL option before gathering them.
+	now := time.Now()
+	r.trackers.Range(func(_, t any) bool {
+		t.(*seriesTracker).expire(now)
+		return true
+	})
+
+	mfs, err := r.gatherer.Gather()
+	for _, mf := range mfs {
+		if md, ok := r.metadata.Load(mf.GetName()); ok && md.(metricMetadata).unit != "" {
+			unit := md.(metricMetadata).unit
+			mf.Unit = &unit
+		}
+	}
+	addGlobalLabels(mfs)
+	return mfs, err
+}
+
+// Registerer returns the underlying Prometheus registerer, e.g. for registering
+// third-party collectors next to the metrics of this package.
+func (r *Registry) Registerer() prometheus.Registerer {
+	return r.registerer
+}
+
+// metricMetadata is the metadata of a metric that isn't part of its gathered metric family.
+type metricMetadata struct {
+	unit string
+
+	// openMetricsType is the type served in the OpenMetrics format for metric types
+	// that Prometheus doesn't support, e.g. "info" or "stateset".
+	openMetricsType string
+}
+
+// openMetricsType returns the OpenMetrics type of the named metric, if it's not supported by Prometheus.
+func (r *Registry) openMetricsType(name string) string {
+	if md, ok := r.metadata.Load(name); ok {
+		return md.(metricMetadata).openMetricsType
+	}
+	return ""
+}
+
+// register registers the collector of the named metric in the metric's registry. If an identical
+// collector is already registered and the WithReuse option is set, the existing collector is returned.
+func register[C prometheus.Collector](o metricOpts, name string, c C) (C, error) {
+	err := o.registry.registerer.Register(c)
+	if err == nil {
+		if md := o.metadata(); md != (metricMetadata{}) {
+			o.registry.metadata.Store(name, md)
+		}
+		return c, nil
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if o.reuse && errors.As(err, &are) {
+		existing, ok := are.ExistingCollector.(C)
+		if !ok {
+			return c, fmt.Errorf("metric already registered with a different type (%T): %w", are.ExistingCollector, err)
+		}
+		return existing, nil
+	}
+
+	return c, err
+}
+
+// httpServer returns the built-in metrics for incoming HTTP requests, creating them on first use.
+func (r *Registry) httpServer() *serverMetrics {
+	r.serverOnce.Do(func() {
+		r.serverMetrics = newServerMetrics(r)
+	})
+	return r.serverMetrics
+}
+
+// httpClient returns the built-in metrics for outgoing HTTP requests, creating them on first use.
+func (r *Registry) httpClient() *clientMetrics {
+	r.clientOnce.Do(func() {
+		r.clientMetrics = newClientMetrics(r)
+	})
+	return r.clientMetrics
+}
+
+// overflowCounter returns the counter of observations folded into overflow series, creating it on first use.
+func (r *Registry) overflowCounter() *CounterMetricLabeled[overflowLabels] {
+	r.overflowOnce.Do(func() {
+		r.overflow = CounterWith[overflowLabels](
+			"metrics_overflow_observations_total",
+			"Total number of observations folded into the overflow series of a metric after reaching its series limit.",
+			WithRegistry(r),
+		)
+	})
+	return &r.overflow
+}
+
+// rejectedCounter returns the counter of observations rejected due to the `values` tag option, creating it on first use.
+func (r *Registry) rejectedCounter() *CounterMetricLabeled[rejectedLabels] {
+	r.rejectedOnce.Do(func() {
+		r.rejected = CounterWith[rejectedLabels](
+			"metrics_rejected_observations_total",
+			"Total number of observations rejected by a metric because of label values that are not allowed.",
+			WithRegistry(r),
+		)
+	})
+	return &r.rejected
+}
+
+// seriesTracker returns the series tracker of a registered labeled metric, creating it on first use.
+// It returns nil if the metric doesn't need one.
+func (r *Registry) seriesTracker(vec *prometheus.MetricVec, o metricOpts, name string, keys []string) *seriesTracker {
+	if cached, ok := r.trackers.Load(vec); ok {
+		return cached.(*seriesTracker)
+	}
+	t := newSeriesTracker(o, name, vec, keys)
+	if t == nil {
+		return nil
+	}
+	cached, _ := r.trackers.LoadOrStore(vec, t)
+	return cached.(*seriesTracker)
+}
+
+// orDefault returns the registry, or DefaultRegistry if it's nil.
+func (r *Registry) orDefault() *Registry {
+	if r == nil {
+		return DefaultRegistry
+	}
+	return r
+}
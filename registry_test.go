@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryIsolation(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+
+	reg1 := NewRegistry()
+	reg2 := NewRegistry()
+
+	// Same metric name in two registries must not panic.
+	c1 := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg1))
+	c2 := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg2))
+
+	c1.Inc(jobLabels{Name: "a"})
+	c2.Add(5, jobLabels{Name: "b"})
+
+	body1 := scrape(t, reg1.Handler())
+	body2 := scrape(t, reg2.Handler())
+
+	if !strings.Contains(body1, `jobs_total{name="a"} 1`) || strings.Contains(body1, `name="b"`) {
+		t.Errorf("unexpected reg1 output:\n%s", body1)
+	}
+	if !strings.Contains(body2, `jobs_total{name="b"} 5`) || strings.Contains(body2, `name="a"`) {
+		t.Errorf("unexpected reg2 output:\n%s", body2)
+	}
+}
+
+func TestRegistryCollectorAndTransport(t *testing.T) {
+	reg := NewRegistry()
+
+	handler := Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(TransportOpts{Registry: reg})(http.DefaultTransport)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`http_requests_total{client_aborted="",endpoint="<no-match>",host="",proto="",status="418"} 1`,
+		`http_client_requests_total{host="",status="418"} 1`,
+		`http_request_duration_seconds_count{endpoint="<no-match>",status="418"} 1`,
+		`http_client_request_duration_seconds_count{host="",status="418"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
+
+func scrape(t *testing.T, h http.Handler) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// scrapeOpenMetrics is like scrape, but negotiates the OpenMetrics exposition format.
+func scrapeOpenMetrics(t *testing.T, h http.Handler) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	h.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Fatalf("expected OpenMetrics content type, got %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func TestHandlerErrors(t *testing.T) {
+	var logged []string
+	reg := NewRegistry(WithErrorLog(loggerFunc(func(v ...any) {
+		logged = append(logged, fmt.Sprintln(v...))
+	})))
+	c := Counter("jobs_total", "Jobs.", WithRegistry(reg))
+	c.Inc()
+
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder()}
+	reg.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", vary)
+	}
+	if len(logged) == 0 || !strings.Contains(logged[0], "connection reset") {
+		t.Errorf("expected logged write error, got %q", logged)
+	}
+}
+
+type loggerFunc func(v ...any)
+
+func (f loggerFunc) Println(v ...any) { f(v...) }
+
+// failingWriter is a response writer whose body writes fail, like when the client disconnects.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestRegistryDuplicateRegistration(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+	type otherLabels struct {
+		Other string `label:"other"`
+	}
+
+	reg := NewRegistry()
+
+	c1, err := NewCounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewCounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg)); err == nil {
+		t.Error("expected error for duplicate registration without WithReuse")
+	}
+
+	c2, err := NewCounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg), WithReuse())
+	if err != nil {
+		t.Fatalf("expected reuse of existing counter, got %v", err)
+	}
+	if c1.vec != c2.vec {
+		t.Error("expected WithReuse to return the existing collector")
+	}
+
+	testCases := []struct {
+		name string
+		fn   func() error
+	}{
+		{
+			name: "different help",
+			fn: func() error {
+				_, err := NewCounterWith[jobLabels]("jobs_total", "Other help.", WithRegistry(reg), WithReuse())
+				return err
+			},
+		},
+		{
+			name: "different labels",
+			fn: func() error {
+				_, err := NewCounterWith[otherLabels]("jobs_total", "Jobs.", WithRegistry(reg), WithReuse())
+				return err
+			},
+		},
+		{
+			name: "different type",
+			fn: func() error {
+				_, err := NewGaugeWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg), WithReuse())
+				return err
+			},
+		},
+		{
+			name: "invalid name",
+			fn: func() error {
+				_, err := NewCounter("Invalid-Name", "Jobs.", WithRegistry(reg))
+				return err
+			},
+		},
+		{
+			name: "invalid labels",
+			fn: func() error {
+				_, err := NewGaugeWith[struct{ Name string }]("no_tags", "No tags.", WithRegistry(reg))
+				return err
+			},
+		},
+		{
+			name: "invalid buckets",
+			fn: func() error {
+				_, err := NewHistogram("bad_buckets", "Bad buckets.", []float64{1, 0.5}, WithRegistry(reg))
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fn()
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			t.Logf("Expected error occurred: %v", err)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"hash/maphash"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowLabelValue is the value of all labels of the overflow series, into which new label sets
+// are folded once a metric reaches the limit set by the WithLimit option.
+const OverflowLabelValue = "__overflow__"
+
+// seriesTracker keeps track of the label sets (series) of a labeled metric,
+// to enforce the series limit and the TTL of the metric.
+type seriesTracker struct {
+	vec      *prometheus.MetricVec
+	keys     []string
+	limit    int
+	ttl      time.Duration
+	seed     maphash.Seed
+	overflow []string
+	dropped  prometheus.Counter
+
+	mu     sync.RWMutex
+	series map[uint64]*trackedSeries
+}
+
+// trackedSeries is a series of a labeled metric.
+type trackedSeries struct {
+	values []string
+
+	// lastUpdate is the time of the last observation in Unix nanoseconds, maintained only with a TTL.
+	lastUpdate atomic.Int64
+}
+
+func newSeriesTracker(o metricOpts, name string, vec *prometheus.MetricVec, keys []string) *seriesTracker {
+	if o.limit <= 0 && o.ttl <= 0 {
+		return nil
+	}
+
+	t := &seriesTracker{
+		vec:    vec,
+		keys:   keys,
+		limit:  o.limit,
+		ttl:    o.ttl,
+		seed:   maphash.MakeSeed(),
+		series: map[uint64]*trackedSeries{},
+	}
+	if o.limit > 0 {
+		t.overflow = make([]string, len(keys))
+		for i := range t.overflow {
+			t.overflow[i] = OverflowLabelValue
+		}
+		t.dropped = o.registry.overflowCounter().With(overflowLabels{Metric: name}).counter
+	}
+	return t
+}
+
+// track records the label values of a series and returns them, or returns the overflow
+// label values if the series is new and the metric already reached its series limit.
+// A nil tracker returns the label values as they are.
+func (t *seriesTracker) track(lvs []string) []string {
+	if t == nil {
+		return lvs
+	}
+
+	h := t.hash(lvs)
+
+	t.mu.RLock()
+	s, ok := t.series[h]
+	if ok && t.ttl > 0 {
+		s.lastUpdate.Store(time.Now().UnixNano())
+	}
+	t.mu.RUnlock()
+	if ok {
+		return lvs
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok = t.series[h]
+	if !ok {
+		if t.limit > 0 && len(t.series) >= t.limit {
+			t.dropped.Inc()
+			return t.overflow
+		}
+		s = &trackedSeries{values: slices.Clone(lvs)}
+		t.series[h] = s
+	}
+	if t.ttl > 0 {
+		s.lastUpdate.Store(time.Now().UnixNano())
+	}
+	return lvs
+}
+
+// untrack removes the series with the label values. A nil tracker does nothing.
+func (t *seriesTracker) untrack(lvs []string) {
+	if t == nil {
+		return
+	}
+
+	h := t.hash(lvs)
+
+	t.mu.Lock()
+	delete(t.series, h)
+	t.mu.Unlock()
+}
+
+// untrackMatching removes all series matching the labels.
+func (t *seriesTracker) untrackMatching(labels prometheus.Labels) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for h, s := range t.series {
+		if t.matches(s.values, labels) {
+			delete(t.series, h)
+		}
+	}
+}
+
+func (t *seriesTracker) matches(lvs []string, labels prometheus.Labels) bool {
+	for i, key := range t.keys {
+		if value, ok := labels[key]; ok && lvs[i] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// reset removes all series. A nil tracker does nothing.
+func (t *seriesTracker) reset() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	clear(t.series)
+	t.mu.Unlock()
+}
+
+// expire deletes the series that weren't updated within the TTL from the metric.
+func (t *seriesTracker) expire(now time.Time) {
+	if t.ttl <= 0 {
+		return
+	}
+	deadline := now.Add(-t.ttl).UnixNano()
+
+	// Series are deleted from the metric under the lock, so that they aren't updated concurrently.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for h, s := range t.series {
+		if s.lastUpdate.Load() < deadline {
+			t.vec.DeleteLabelValues(s.values...)
+			delete(t.series, h)
+		}
+	}
+}
+
+func (t *seriesTracker) hash(lvs []string) uint64 {
+	var h maphash.Hash
+	h.SetSeed(t.seed)
+	for _, v := range lvs {
+		h.WriteString(v)
+		h.WriteByte(0)
+	}
+	return h.Sum64()
+}
+
+// overflowLabels defines labels for the counter of observations folded into overflow series.
+type overflowLabels struct {
+	Metric string `label:"metric"`
+}
+
+// WithLimit limits the number of distinct label sets (series) of a labeled metric.
+// Once the limit is reached, observations with new label sets are folded into a single
+// overflow series with all labels set to OverflowLabelValue, and counted by the
+// metrics_overflow_observations_total metric. Handles returned by With are counted once,
+// when they're created, unless the metric has a TTL. Zero means no limit.
+func WithLimit(limit int) Option {
+	return func(o *metricOpts) {
+		o.limit = limit
+	}
+}
+
+// WithTTL deletes the series of a labeled metric that weren't updated within the TTL,
+// e.g. the series of a worker that disappeared. Expired series are deleted when the metrics
+// are gathered by the Handler or Gatherer of the registry. Handles returned by With look up
+// their series on every use, including reads, so that they keep it from expiring and recreate
+// it once it expired. Zero means series never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *metricOpts) {
+		o.ttl = ttl
+	}
+}
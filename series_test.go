@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeriesLimit(t *testing.T) {
+	type userLabels struct {
+		User   string `label:"user"`
+		Status int    `label:"status"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[userLabels]("requests_total", "Requests.", WithRegistry(reg), WithLimit(2))
+
+	c.Inc(userLabels{User: "a", Status: 200})
+	c.Inc(userLabels{User: "b", Status: 200})
+	c.Inc(userLabels{User: "a", Status: 200}) // Existing series still work at the limit.
+	c.Inc(userLabels{User: "c", Status: 200})
+	c.Add(2, userLabels{User: "d", Status: 500})
+	bound := c.With(userLabels{User: "e", Status: 200})
+	bound.Inc()
+
+	// Reused metrics share the series limit.
+	reused := CounterWith[userLabels]("requests_total", "Requests.", WithRegistry(reg), WithLimit(2), WithReuse())
+	reused.Inc(userLabels{User: "f", Status: 200})
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`requests_total{status="200",user="a"} 2`,
+		`requests_total{status="200",user="b"} 1`,
+		`requests_total{status="__overflow__",user="__overflow__"} 5`,
+		`metrics_overflow_observations_total{metric="requests_total"} 4`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{`user="c"`, `user="d"`, `user="e"`, `user="f"`} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, body)
+		}
+	}
+}
+
+func TestDeleteSeries(t *testing.T) {
+	type workerLabels struct {
+		Tenant string `label:"tenant"`
+		Worker int    `label:"worker"`
+	}
+
+	reg := NewRegistry()
+	g := GaugeWith[workerLabels]("worker_jobs", "Jobs.", WithRegistry(reg), WithLimit(3))
+
+	g.Set(1, workerLabels{Tenant: "a", Worker: 1})
+	g.Set(2, workerLabels{Tenant: "a", Worker: 2})
+	g.Set(3, workerLabels{Tenant: "b", Worker: 1})
+
+	if !g.Delete(workerLabels{Tenant: "a", Worker: 1}) {
+		t.Error("expected Delete to report the deleted series")
+	}
+	if g.Delete(workerLabels{Tenant: "a", Worker: 1}) {
+		t.Error("expected Delete to report a missing series")
+	}
+
+	// Deleted series no longer count towards the series limit.
+	g.Set(4, workerLabels{Tenant: "c", Worker: 1})
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`worker_jobs{tenant="a",worker="2"} 2`,
+		`worker_jobs{tenant="b",worker="1"} 3`,
+		`worker_jobs{tenant="c",worker="1"} 4`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `worker_jobs{tenant="a",worker="1"}`) || strings.Contains(body, OverflowLabelValue) {
+		t.Errorf("unexpected series in output:\n%s", body)
+	}
+
+	if n := g.DeletePartialMatch(workerLabels{Worker: 1}); n != 2 {
+		t.Errorf("expected 2 deleted series, got %d", n)
+	}
+	body = scrape(t, reg.Handler())
+	if !strings.Contains(body, `worker_jobs{tenant="a",worker="2"} 2`) || strings.Contains(body, `worker="1"`) {
+		t.Errorf("expected only the series of worker 2 in output:\n%s", body)
+	}
+
+	g.Reset()
+	if body := scrape(t, reg.Handler()); strings.Contains(body, "worker_jobs{") {
+		t.Errorf("unexpected series after Reset in output:\n%s", body)
+	}
+
+	// After a reset, the full series limit is available again.
+	for i := range 3 {
+		g.Set(1, workerLabels{Tenant: "d", Worker: i})
+	}
+	if body := scrape(t, reg.Handler()); strings.Contains(body, OverflowLabelValue) {
+		t.Errorf("unexpected overflow series in output:\n%s", body)
+	}
+}
+
+func TestSeriesTTL(t *testing.T) {
+	type workerLabels struct {
+		Worker string `label:"worker"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[workerLabels]("worker_jobs_total", "Jobs.", WithRegistry(reg), WithTTL(100*time.Millisecond))
+
+	c.Inc(workerLabels{Worker: "gone"})
+	c.Inc(workerLabels{Worker: "active"})
+	time.Sleep(150 * time.Millisecond)
+	c.Inc(workerLabels{Worker: "active"})
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, `worker_jobs_total{worker="active"} 2`) {
+		t.Errorf("expected active series in output:\n%s", body)
+	}
+	if strings.Contains(body, `worker="gone"`) {
+		t.Errorf("expected expired series to be deleted:\n%s", body)
+	}
+
+	// Expired series start from zero when they reappear.
+	c.Inc(workerLabels{Worker: "gone"})
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, `worker_jobs_total{worker="gone"} 1`) {
+		t.Errorf("expected recreated series in output:\n%s", body)
+	}
+}
+
+func TestSeriesTTLWith(t *testing.T) {
+	type workerLabels struct {
+		Worker string `label:"worker"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[workerLabels]("worker_jobs_total", "Jobs.", WithRegistry(reg), WithTTL(100*time.Millisecond))
+	active := c.With(workerLabels{Worker: "active"})
+	idle := c.With(workerLabels{Worker: "idle"})
+
+	// Updates through handles keep their series from expiring.
+	active.Inc()
+	idle.Inc()
+	time.Sleep(60 * time.Millisecond)
+	active.Inc()
+	time.Sleep(60 * time.Millisecond)
+	active.Inc()
+
+	body := scrape(t, reg.Handler())
+	if !strings.Contains(body, `worker_jobs_total{worker="active"} 3`) {
+		t.Errorf("expected active series in output:\n%s", body)
+	}
+	if strings.Contains(body, `worker="idle"`) {
+		t.Errorf("expected expired series to be deleted:\n%s", body)
+	}
+
+	// Handles recreate their series once it expired.
+	idle.Inc()
+	if body := scrape(t, reg.Handler()); !strings.Contains(body, `worker_jobs_total{worker="idle"} 1`) {
+		t.Errorf("expected recreated series in output:\n%s", body)
+	}
+	if v := idle.Value(); v != 1 {
+		t.Errorf("expected value 1 of recreated series, got %v", v)
+	}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// StateSetWith creates a state set metric with typed labels, which represents an enum-like state,
+// e.g. the state of a circuit breaker or the leader status, where exactly one of the states is
+// active (1) and all others are inactive (0). It's served with the OpenMetrics stateset type,
+// or as a gauge in the Prometheus text format. The state is exported in a label named like the metric:
+//
+//	type CircuitState string
+//
+//	var circuitState = metrics.StateSetWith[circuitLabels]("circuit_state", "State of the circuit breaker",
+//		[]CircuitState{"closed", "open", "half_open"})
+//
+//	circuitState.Set("open", circuitLabels{Circuit: "payments"})
+func StateSetWith[T any, S ~string](name, help string, states []S, opts ...Option) StateSetMetricLabeled[T, S] {
+	s, err := NewStateSetWith[T](name, help, states, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewStateSetWith creates a state set metric with typed labels, or returns an error if the metric,
+// its states or its labels are invalid or it can't be registered
+func NewStateSetWith[T any, S ~string](name, help string, states []S, opts ...Option) (StateSetMetricLabeled[T, S], error) {
+	o := newMetricOpts(opts)
+	o.openMetricsType = "stateset"
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return StateSetMetricLabeled[T, S]{}, err
+	}
+	if err := o.validate(dto.MetricType_GAUGE, name, ls.keys); err != nil {
+		return StateSetMetricLabeled[T, S]{}, err
+	}
+	if err := validStateSet(name, states, ls.keys, o.constLabels); err != nil {
+		return StateSetMetricLabeled[T, S]{}, err
+	}
+
+	c := &stateSetCollector{
+		desc:   prometheus.NewDesc(name, help, append(slices.Clone(ls.keys), name), o.constLabels),
+		labels: ls,
+		reject: o.labelValuePolicy == LabelValuesReject,
+		states: make([]string, len(states)),
+		series: map[string]*stateSetSeries{},
+	}
+	for i, state := range states {
+		c.states[i] = string(state)
+	}
+
+	c, err = register(o, name, c)
+	if err != nil {
+		return StateSetMetricLabeled[T, S]{}, err
+	}
+	return StateSetMetricLabeled[T, S]{collector: c}, nil
+}
+
+func validStateSet[S ~string](name string, states []S, keys []string, constLabels map[string]string) error {
+	if !isValidLabelName(name) {
+		return fmt.Errorf("invalid state set name %s: it's used as the name of the state label, which %s", name, loadNameValidation().labelNameRule())
+	}
+	if _, ok := constLabels[name]; ok || slices.Contains(keys, name) {
+		return fmt.Errorf("label %q is reserved for the states of the state set", name)
+	}
+	if len(states) == 0 {
+		return errors.New("state set must have at least one state")
+	}
+	for i, state := range states {
+		if slices.Contains(states[:i], state) {
+			return fmt.Errorf("duplicate state %q of state set %s", state, name)
+		}
+	}
+	return nil
+}
+
+// StateSetMetricLabeled represents a state set metric with typed labels
+type StateSetMetricLabeled[T any, S ~string] struct {
+	collector *stateSetCollector
+}
+
+// Set sets the active state for the given labels and deactivates all other states.
+// States that were not passed to StateSetWith are ignored.
+func (s *StateSetMetricLabeled[T, S]) Set(state S, labels T) {
+	c := s.collector
+	i := slices.Index(c.states, string(state))
+	if i < 0 {
+		return
+	}
+
+	values := labelValues(c.labels, nil, &labels)
+	if !c.labels.allow(values, c.reject) {
+		return
+	}
+	key := strings.Join(values, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if series, ok := c.series[key]; ok {
+		series.state = i
+		return
+	}
+	c.series[key] = &stateSetSeries{values: values, state: i}
+}
+
+// Delete deletes the series with the given labels and reports whether it existed.
+func (s *StateSetMetricLabeled[T, S]) Delete(labels T) bool {
+	c := s.collector
+	values := labelValues(c.labels, nil, &labels)
+	if !c.labels.allow(values, c.reject) {
+		return false
+	}
+	key := strings.Join(values, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.series[key]
+	delete(c.series, key)
+	return ok
+}
+
+// stateSetCollector collects the series of a state set metric, one per state and label set.
+type stateSetCollector struct {
+	desc   *prometheus.Desc
+	labels *labelInfo
+	reject bool
+	states []string
+
+	mu     sync.Mutex
+	series map[string]*stateSetSeries
+}
+
+type stateSetSeries struct {
+	values []string
+	state  int
+}
+
+func (c *stateSetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *stateSetCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	series := make([]stateSetSeries, 0, len(c.series))
+	for _, s := range c.series {
+		series = append(series, *s)
+	}
+	c.mu.Unlock()
+
+	for _, s := range series {
+		values := append(slices.Clone(s.values), "")
+		for i, state := range c.states {
+			value := 0.0
+			if i == s.state {
+				value = 1
+			}
+			values[len(values)-1] = state
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, values...)
+		}
+	}
+}
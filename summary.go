@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Summary creates a summary metric with the given quantile objectives, e.g. map[float64]float64{0.5: 0.05, 0.99: 0.001}
+func Summary(name, help string, objectives map[float64]float64, opts ...Option) SummaryMetric {
+	s, err := NewSummary(name, help, objectives, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewSummary creates a summary metric, or returns an error if the metric is invalid
+// or can't be registered
+func NewSummary(name, help string, objectives map[float64]float64, opts ...Option) (SummaryMetric, error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	if err := o.validate(dto.MetricType_SUMMARY, name, nil); err != nil {
+		return SummaryMetric{}, err
+	}
+	if err := o.validSummary(objectives, nil); err != nil {
+		return SummaryMetric{}, err
+	}
+	vec, err := register(o, name, prometheus.NewSummaryVec(o.summaryOpts(name, help, objectives), []string{}))
+	if err != nil {
+		return SummaryMetric{}, err
+	}
+	return SummaryMetric{observer: vec.WithLabelValues()}, nil
+}
+
+// SummaryWith creates a summary metric with typed labels
+func SummaryWith[T any](name, help string, objectives map[float64]float64, opts ...Option) SummaryMetricLabeled[T] {
+	s, err := NewSummaryWith[T](name, help, objectives, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewSummaryWith creates a summary metric with typed labels, or returns an error if the metric
+// or its labels are invalid or it can't be registered
+func NewSummaryWith[T any](name, help string, objectives map[float64]float64, opts ...Option) (SummaryMetricLabeled[T], error) {
+	o := newMetricOpts(opts)
+	name = o.registry.fqName(name)
+	ls, err := getLabelInfo[T]()
+	if err != nil {
+		return SummaryMetricLabeled[T]{}, err
+	}
+	if err := o.validate(dto.MetricType_SUMMARY, name, ls.keys); err != nil {
+		return SummaryMetricLabeled[T]{}, err
+	}
+	if err := o.validSummary(objectives, ls.keys); err != nil {
+		return SummaryMetricLabeled[T]{}, err
+	}
+	vec, err := register(o, name, prometheus.NewSummaryVec(o.summaryOpts(name, help, objectives), ls.keys))
+	if err != nil {
+		return SummaryMetricLabeled[T]{}, err
+	}
+	return SummaryMetricLabeled[T]{
+		vec: vec,
+		labeled: newLabeledVec(o, name, ls, vec.MetricVec, vec.WithLabelValues, func() prometheus.Observer {
+			return prometheus.NewSummary(o.summaryOpts(name, help, objectives))
+		}),
+	}, nil
+}
+
+// WithMaxAge sets the duration for which observations stay relevant for the quantiles
+// of a summary metric. Defaults to 10 minutes. Only applies to Summary and SummaryWith.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(o *metricOpts) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithAgeBuckets sets the number of buckets used to exclude observations older than MaxAge
+// from the quantiles of a summary metric. Defaults to 5. Only applies to Summary and SummaryWith.
+func WithAgeBuckets(ageBuckets uint32) Option {
+	return func(o *metricOpts) {
+		o.ageBuckets = ageBuckets
+	}
+}
+
+func (o *metricOpts) summaryOpts(name, help string, objectives map[float64]float64) prometheus.SummaryOpts {
+	return prometheus.SummaryOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: o.constLabels,
+		Objectives:  objectives,
+		MaxAge:      o.maxAge,
+		AgeBuckets:  o.ageBuckets,
+	}
+}
+
+// validSummary checks the summary configuration, which prometheus.NewSummaryVec would otherwise panic on.
+func (o *metricOpts) validSummary(objectives map[float64]float64, keys []string) error {
+	if _, ok := o.constLabels["quantile"]; ok || slices.Contains(keys, "quantile") {
+		return errors.New(`label "quantile" is reserved for summary quantiles`)
+	}
+	for q, e := range objectives {
+		if q < 0 || q > 1 || e < 0 || e > 1 {
+			return fmt.Errorf("invalid summary objective %v: %v (quantile and error must be within [0, 1])", q, e)
+		}
+	}
+	if o.maxAge < 0 {
+		return fmt.Errorf("invalid summary max age: %v", o.maxAge)
+	}
+	return nil
+}
+
+type SummaryMetric struct {
+	observer prometheus.Observer
+
+	// bound looks up the series again on every use of handles of metrics with a TTL, see withBoundLabelValues.
+	bound func() prometheus.Observer
+}
+
+// metric returns the series of the handle.
+func (s *SummaryMetric) metric() prometheus.Observer {
+	if s.bound != nil {
+		return s.bound()
+	}
+	return s.observer
+}
+
+func (s *SummaryMetric) Observe(value float64) {
+	s.metric().Observe(value)
+}
+
+// SummaryMetricLabeled represents a summary metric with typed labels
+type SummaryMetricLabeled[T any] struct {
+	vec     *prometheus.SummaryVec
+	labeled *labeledVec[prometheus.Observer]
+}
+
+func (s *SummaryMetricLabeled[T]) Observe(value float64, labels T) {
+	withLabelValues(s.labeled, labels).Observe(value)
+}
+
+// With returns the summary for the given labels. The returned summary can be cached
+// and used in hot paths to skip the label lookup on every call.
+func (s *SummaryMetricLabeled[T]) With(labels T) SummaryMetric {
+	observer, bound := withBoundLabelValues(s.labeled, labels)
+	return SummaryMetric{observer: observer, bound: bound}
+}
+
+// Init creates the series for all combinations of the allowed values of label fields restricted
+// by the `values` tag option, so that they are exported with zero values before the first observation.
+// Values of the other label fields are taken from labels.
+func (s *SummaryMetricLabeled[T]) Init(labels T) {
+	initLabelValues(s.labeled, labels)
+}
+
+// Delete deletes the series with the given labels, e.g. of a worker that disappeared,
+// and reports whether it existed.
+func (s *SummaryMetricLabeled[T]) Delete(labels T) bool {
+	return deleteLabelValues(s.labeled, labels)
+}
+
+// DeletePartialMatch deletes all series matching the non-zero fields of labels, e.g. all series
+// of a tenant, and returns the number of deleted series. If all fields are zero, all series are deleted.
+func (s *SummaryMetricLabeled[T]) DeletePartialMatch(labels T) int {
+	return deletePartialMatch(s.labeled, labels)
+}
+
+// Reset deletes all series.
+func (s *SummaryMetricLabeled[T]) Reset() {
+	resetLabelValues(s.labeled)
+}
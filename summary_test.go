@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummaryWith(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+
+	reg := NewRegistry()
+	s := SummaryWith[jobLabels]("job_duration_seconds", "Job duration.",
+		map[float64]float64{0.5: 0.05, 0.99: 0.001},
+		WithRegistry(reg), WithMaxAge(time.Minute), WithAgeBuckets(3),
+	)
+
+	for i := 1; i <= 100; i++ {
+		s.Observe(float64(i), jobLabels{Name: "a"})
+	}
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`job_duration_seconds{name="a",quantile="0.5"} 50`,
+		`job_duration_seconds{name="a",quantile="0.99"} 99`,
+		`job_duration_seconds_sum{name="a"} 5050`,
+		`job_duration_seconds_count{name="a"} 100`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
+
+func TestSummaryValidation(t *testing.T) {
+	type quantileLabels struct {
+		Quantile string `label:"quantile"`
+	}
+
+	reg := NewRegistry()
+
+	if _, err := NewSummaryWith[quantileLabels]("a_seconds", "A.", nil, WithRegistry(reg)); err == nil {
+		t.Error("expected error for reserved quantile label")
+	}
+	if _, err := NewSummary("b_seconds", "B.", map[float64]float64{1.5: 0.01}, WithRegistry(reg)); err == nil {
+		t.Error("expected error for invalid objective")
+	}
+	if _, err := NewSummary("c_seconds", "C.", nil, WithRegistry(reg), WithMaxAge(-time.Second)); err == nil {
+		t.Error("expected error for negative max age")
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import "time"
+
+// StartTimer starts timing an operation and returns a function that observes the elapsed time
+// in seconds and returns it, e.g.:
+//
+//	defer jobDuration.StartTimer()()
+func (h *HistogramMetric) StartTimer() func() time.Duration {
+	start := time.Now()
+	return func() time.Duration {
+		d := time.Since(start)
+		h.Observe(d.Seconds())
+		return d
+	}
+}
+
+// StartTimer starts timing an operation and returns a function that observes the elapsed time
+// in seconds with the given labels and returns it. Labels are passed when stopping the timer,
+// since some of them, e.g. the status, are usually only known at the end of the operation:
+//
+//	stop := jobDuration.StartTimer()
+//	err := job.Run()
+//	stop(jobLabels{Name: job.Name, Status: status(err)})
+//
+// To pass the labels when starting the timer, use With(labels).StartTimer().
+func (h *HistogramMetricLabeled[T]) StartTimer() func(labels T) time.Duration {
+	start := time.Now()
+	return func(labels T) time.Duration {
+		d := time.Since(start)
+		h.Observe(d.Seconds(), labels)
+		return d
+	}
+}
+
+// Outcomes of operations timed by Time.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// OutcomeLabels defines the "outcome" label set by Time. Embed it in the label struct of a histogram:
+//
+//	type jobLabels struct {
+//		metrics.OutcomeLabels
+//		Name string `label:"name"`
+//	}
+type OutcomeLabels struct {
+	Outcome string `label:"outcome,values=success|error"`
+}
+
+func (l *OutcomeLabels) setOutcome(outcome string) {
+	l.Outcome = outcome
+}
+
+// outcomeSetter is implemented by label structs embedding OutcomeLabels.
+type outcomeSetter[T any] interface {
+	*T
+	setOutcome(outcome string)
+}
+
+// Time calls fn and observes its duration in seconds with the given labels, with the outcome label
+// set to "success" or "error" depending on the returned error, which is passed through:
+//
+//	err := metrics.Time(&jobDuration, jobLabels{Name: "sync"}, syncUsers)
+func Time[T any, PT outcomeSetter[T]](h *HistogramMetricLabeled[T], labels T, fn func() error) error {
+	stop := h.StartTimer()
+	err := fn()
+
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	PT(&labels).setOutcome(outcome)
+	stop(labels)
+
+	return err
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartTimer(t *testing.T) {
+	type jobLabels struct {
+		Status string `label:"status"`
+	}
+
+	reg := NewRegistry()
+	h := Histogram("job_duration_seconds", "Job duration.", []float64{0.001, 10}, WithRegistry(reg))
+	hl := HistogramWith[jobLabels]("labeled_job_duration_seconds", "Job duration.", []float64{0.001, 10}, WithRegistry(reg))
+
+	stop := h.StartTimer()
+	time.Sleep(2 * time.Millisecond)
+	if d := stop(); d < 2*time.Millisecond {
+		t.Errorf("expected duration of at least 2ms, got %v", d)
+	}
+
+	stopLabeled := hl.StartTimer()
+	time.Sleep(2 * time.Millisecond)
+	stopLabeled(jobLabels{Status: "ok"})
+
+	bound := hl.With(jobLabels{Status: "error"})
+	bound.StartTimer()()
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`job_duration_seconds_bucket{le="0.001"} 0`,
+		`job_duration_seconds_count 1`,
+		`labeled_job_duration_seconds_bucket{status="ok",le="0.001"} 0`,
+		`labeled_job_duration_seconds_count{status="ok"} 1`,
+		`labeled_job_duration_seconds_count{status="error"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	type jobLabels struct {
+		OutcomeLabels
+		Name string `label:"name"`
+	}
+
+	reg := NewRegistry()
+	h := HistogramWith[jobLabels]("job_duration_seconds", "Job duration.", []float64{1}, WithRegistry(reg))
+
+	if err := Time(&h, jobLabels{Name: "sync"}, func() error { return nil }); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	errFailed := errors.New("failed")
+	if err := Time(&h, jobLabels{Name: "sync"}, func() error { return errFailed }); err != errFailed {
+		t.Errorf("expected error to be passed through, got %v", err)
+	}
+
+	body := scrape(t, reg.Handler())
+	for _, want := range []string{
+		`job_duration_seconds_count{name="sync",outcome="success"} 1`,
+		`job_duration_seconds_count{name="sync",outcome="error"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
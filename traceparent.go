@@ -0,0 +1,62 @@
+package metrics
+
+import "strings"
+
+// TraceparentHeader is the W3C Trace Context header propagating the trace of a request,
+// see https://www.w3.org/TR/trace-context/#traceparent-header.
+const TraceparentHeader = "traceparent"
+
+// parseTraceparent returns the trace ID of a W3C traceparent header value, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". It returns false if the header
+// is invalid or the trace isn't sampled, since exemplars of unsampled traces lead nowhere.
+func parseTraceparent(header string) (traceID string, ok bool) {
+	header = strings.TrimSpace(header)
+
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	const length = 2 + 1 + 32 + 1 + 16 + 1 + 2
+	if len(header) < length || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return "", false
+	}
+
+	version, traceID, parentID, flags := header[0:2], header[3:35], header[36:52], header[53:55]
+	if !isLowerHex(version) || version == "ff" || !isLowerHex(flags) {
+		return "", false
+	}
+	// Version 00 has a fixed length, future versions may append fields.
+	if len(header) > length && (version == "00" || header[length] != '-') {
+		return "", false
+	}
+	if !isLowerHex(traceID) || isZeros(traceID) || !isLowerHex(parentID) || isZeros(parentID) {
+		return "", false
+	}
+
+	// The sampled flag is the least significant bit of trace-flags.
+	if hexValue(flags[1])&1 == 0 {
+		return "", false
+	}
+	return traceID, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if hexValue(s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeros(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// hexValue returns the value of a lowercase hex digit, or -1 if c isn't one.
+func hexValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	}
+	return -1
+}
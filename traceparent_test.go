@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tt := []struct {
+		header  string
+		traceID string
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03 ", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", ""},       // Not sampled.
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", ""}, // Version 00 has a fixed length.
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01extra", ""},  // Missing separator.
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},       // Invalid version.
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},       // All-zero trace ID.
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", ""},       // All-zero parent ID.
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", ""},       // Uppercase.
+		{"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", ""},         // Short trace ID.
+		{"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01", ""},       // Invalid separators.
+		{"", ""},
+	}
+
+	for _, tc := range tt {
+		traceID, ok := parseTraceparent(tc.header)
+		if traceID != tc.traceID || ok != (tc.traceID != "") {
+			t.Errorf("parseTraceparent(%q) = %q, %v, want %q", tc.header, traceID, ok, tc.traceID)
+		}
+	}
+}
+
+func TestTraceparentExemplars(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	reg := NewRegistry()
+	backend := httptest.NewServer(Collector(CollectorOpts{Registry: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer backend.Close()
+
+	client := &http.Client{Transport: Transport(TransportOpts{Registry: reg})(http.DefaultTransport)}
+	req, _ := http.NewRequest("GET", backend.URL, nil)
+	req.Header.Set(TraceparentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	body := scrapeOpenMetrics(t, reg.Handler())
+	for _, metric := range []string{"http_request_duration_seconds_bucket{", "http_client_request_duration_seconds_bucket{"} {
+		if !hasExemplar(body, metric, traceID) {
+			t.Errorf("expected exemplar on %s in output:\n%s", metric, body)
+		}
+	}
+}
+
+func TestTraceIDExtractor(t *testing.T) {
+	reg := NewRegistry()
+	handler := Collector(CollectorOpts{
+		Registry: reg,
+		TraceID:  func(ctx context.Context) string { return "custom" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// The extractor takes precedence over the traceparent header.
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	body := scrapeOpenMetrics(t, reg.Handler())
+	if !hasExemplar(body, "http_request_duration_seconds_bucket{", "custom") {
+		t.Errorf("expected exemplar with custom trace ID in output:\n%s", body)
+	}
+}
+
+func TestInvalidTraceIDExtractor(t *testing.T) {
+	reg := NewRegistry()
+	handler := Collector(CollectorOpts{
+		Registry: reg,
+		TraceID:  func(ctx context.Context) string { return strings.Repeat("a", 200) },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Invalid trace IDs of the extractor fall back to the traceparent header.
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	body := scrapeOpenMetrics(t, reg.Handler())
+	if !hasExemplar(body, "http_request_duration_seconds_bucket{", "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Errorf("expected exemplar with traceparent trace ID in output:\n%s", body)
+	}
+}
+
+// hasExemplar reports whether any sample of the metric has a trace_id exemplar with the trace ID.
+func hasExemplar(body, metric, traceID string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, metric) && strings.Contains(line, `# {trace_id="`+traceID+`"}`) {
+			return true
+		}
+	}
+	return false
+}
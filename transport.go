@@ -8,15 +8,27 @@ import (
 	"time"
 )
 
-var (
-	clientRequestsCounter  = CounterWith[outgoingRequestLabels]("http_client_requests_total", "Total number of outgoing HTTP requests.")
-	clientInflightGauge    = GaugeWith[outgoingInflightLabels]("http_client_requests_inflight", "Number of outgoing HTTP requests currently in flight.")
-	clientRequestHistogram = HistogramWith[outgoingRequestLabels](
-		"http_client_request_duration_seconds",
-		"Response latency in seconds for completed outgoing HTTP requests.",
-		[]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 25, 50, 100},
-	)
-)
+// clientMetrics holds the built-in metrics for outgoing HTTP requests of a single registry.
+type clientMetrics struct {
+	clientRequestsCounter  CounterMetricLabeled[outgoingRequestLabels]
+	clientInflightGauge    GaugeMetricLabeled[outgoingInflightLabels]
+	clientRequestHistogram HistogramMetricLabeled[outgoingRequestLabels]
+}
+
+func newClientMetrics(reg *Registry) *clientMetrics {
+	return &clientMetrics{
+		clientRequestsCounter: CounterWith[outgoingRequestLabels]("http_client_requests_total", "Total number of outgoing HTTP requests.", WithRegistry(reg)),
+		clientInflightGauge:   GaugeWith[outgoingInflightLabels]("http_client_requests_inflight", "Number of outgoing HTTP requests currently in flight.", WithRegistry(reg)),
+		clientRequestHistogram: HistogramWith[outgoingRequestLabels](
+			"http_client_request_duration_seconds",
+			"Response latency in seconds for completed outgoing HTTP requests.",
+			durationBuckets,
+			WithRegistry(reg),
+			WithNativeHistogram(durationNativeHistogram),
+			WithUnit("seconds"),
+		),
+	}
+}
 
 // TransportOpts configures the HTTP client metrics transport.
 type TransportOpts struct {
@@ -24,17 +36,25 @@ type TransportOpts struct {
 	// WARNING: High cardinality risk - only enable for limited, known hosts. Do not enable
 	// for user-input URLs, crawlers, or dynamically generated hosts.
 	Host bool
+
+	// TraceID optionally extracts the trace ID of a request from its context, e.g. from an OpenTelemetry span.
+	// The trace ID is attached as a trace_id exemplar to the metrics of the request. If nil, empty or invalid,
+	// the trace ID set by ContextWithTraceID or the sampled trace of the W3C traceparent request header is used.
+	TraceID func(ctx context.Context) string
+
+	// Registry is the registry to record metrics into. If nil, DefaultRegistry is used.
+	Registry *Registry
 }
 
 // outgoingRequestLabels defines labels for the counter of total outgoing HTTP requests.
 type outgoingRequestLabels struct {
-	Host   string `label:"host"`
+	Host   string `label:"host,sanitize,maxlen=255"`
 	Status string `label:"status"`
 }
 
 // outgoingInflightLabels defines labels for the gauge of in-flight outgoing HTTP requests.
 type outgoingInflightLabels struct {
-	Host string `label:"host"`
+	Host string `label:"host,sanitize,maxlen=255"`
 }
 
 // Transport returns a new http.RoundTripper that automatically tracks Prometheus metrics
@@ -42,7 +62,12 @@ type outgoingInflightLabels struct {
 // - http_client_requests_total: Total number of outgoing HTTP requests
 // - http_client_requests_inflight: Number of outgoing HTTP requests currently in flight
 // - http_client_request_duration_seconds: Response latency in seconds for completed requests
+//
+// The trace ID of the request, see TransportOpts.TraceID, is attached as an exemplar
+// to http_client_requests_total and http_client_request_duration_seconds.
 func Transport(opts TransportOpts) func(http.RoundTripper) http.RoundTripper {
+	m := opts.Registry.orDefault().httpClient()
+
 	return func(next http.RoundTripper) http.RoundTripper {
 		return roundTripperFunc(func(req *http.Request) (resp *http.Response, err error) {
 			startTime := time.Now().UTC()
@@ -54,12 +79,12 @@ func Transport(opts TransportOpts) func(http.RoundTripper) http.RoundTripper {
 			}
 
 			// Increment inflight counter
-			clientInflightGauge.Inc(inflightLabels)
+			m.clientInflightGauge.Inc(inflightLabels)
 
 			// Defer recording metrics after the request is complete
 			defer func() {
 				// Decrement inflight counter
-				clientInflightGauge.Dec(inflightLabels)
+				m.clientInflightGauge.Dec(inflightLabels)
 
 				// Create labels based on enabled options
 				labels := outgoingRequestLabels{}
@@ -79,13 +104,16 @@ func Transport(opts TransportOpts) func(http.RoundTripper) http.RoundTripper {
 					labels.Host = req.URL.Host
 				}
 
+				// Link the metrics to the trace of the request, if any.
+				exemplar := traceExemplar(req.Context(), req.Header, opts.TraceID)
+
 				// Track total number of requests.
-				clientRequestsCounter.Inc(labels)
+				m.clientRequestsCounter.IncWithExemplar(labels, exemplar)
 
 				// Observe histogram of completed requests.
 				if resp != nil {
 					duration := time.Since(startTime).Seconds()
-					clientRequestHistogram.Observe(duration, labels)
+					m.clientRequestHistogram.ObserveWithExemplar(duration, labels, exemplar)
 				}
 			}()
 
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// HistogramSnapshot is a point-in-time copy of the observations of a histogram series.
+type HistogramSnapshot struct {
+	// Count is the number of observations.
+	Count uint64
+
+	// Sum is the sum of the observed values.
+	Sum float64
+
+	// Buckets are the classic histogram buckets in increasing order of their upper bounds,
+	// ending with the +Inf bucket. Counts are cumulative, as in the exposition format. Empty
+	// for native histograms without classic buckets.
+	Buckets []HistogramBucket
+}
+
+// HistogramBucket is a classic histogram bucket of a HistogramSnapshot.
+type HistogramBucket struct {
+	// UpperBound is the inclusive upper bound of the bucket.
+	UpperBound float64
+
+	// Count is the number of observations less than or equal to UpperBound.
+	Count uint64
+}
+
+// Quantile estimates the φ-quantile (0 <= φ <= 1), e.g. 0.99 for the 99th percentile, from the
+// buckets by linear interpolation within the bucket of the quantile, like the PromQL histogram_quantile
+// function. Its precision depends on the bucket boundaries. If the quantile falls into the +Inf bucket,
+// the upper bound of the previous bucket is returned. It returns NaN if there are no observations
+// or buckets, or if φ is out of range.
+func (s HistogramSnapshot) Quantile(φ float64) float64 {
+	if φ < 0 || φ > 1 || math.IsNaN(φ) || s.Count == 0 || len(s.Buckets) == 0 {
+		return math.NaN()
+	}
+
+	rank := φ * float64(s.Count)
+	for i, b := range s.Buckets {
+		if float64(b.Count) < rank || b.Count == 0 {
+			continue
+		}
+		if math.IsInf(b.UpperBound, 1) {
+			if i == 0 {
+				return math.NaN()
+			}
+			return s.Buckets[i-1].UpperBound
+		}
+
+		lower, lowerCount := 0.0, uint64(0)
+		if i > 0 {
+			lower, lowerCount = s.Buckets[i-1].UpperBound, s.Buckets[i-1].Count
+		} else if b.UpperBound <= 0 {
+			// The lowest bucket has no lower bound, so its upper bound is the best estimate.
+			return b.UpperBound
+		}
+		return lower + (b.UpperBound-lower)*(rank-float64(lowerCount))/float64(b.Count-lowerCount)
+	}
+	return s.Buckets[len(s.Buckets)-1].UpperBound
+}
+
+// newHistogramSnapshot copies the observations of a histogram series.
+func newHistogramSnapshot(m *dto.Metric) HistogramSnapshot {
+	h := m.GetHistogram()
+	s := HistogramSnapshot{
+		Count: h.GetSampleCount(),
+		Sum:   h.GetSampleSum(),
+	}
+	if len(h.GetBucket()) == 0 {
+		return s
+	}
+	s.Buckets = make([]HistogramBucket, 0, len(h.GetBucket())+1)
+	for _, b := range h.GetBucket() {
+		s.Buckets = append(s.Buckets, HistogramBucket{UpperBound: b.GetUpperBound(), Count: b.GetCumulativeCount()})
+	}
+	if !math.IsInf(s.Buckets[len(s.Buckets)-1].UpperBound, 1) {
+		s.Buckets = append(s.Buckets, HistogramBucket{UpperBound: math.Inf(1), Count: s.Count})
+	}
+	return s
+}
+
+// readMetric returns the current state of a series. The Prometheus metrics are safe to read
+// concurrently with updates, e.g. histograms swap their hot and cold counts to get a consistent snapshot.
+func readMetric(metric prometheus.Metric) *dto.Metric {
+	m := &dto.Metric{}
+	_ = metric.Write(m) // Only fails for invalid exemplars, which are rejected when they're added.
+	return m
+}
+
+// lookupLabelValues returns the current state of the series for the given label struct, or an empty
+// series if it doesn't exist. Unlike withLabelValues, it doesn't create the series, so reading values
+// doesn't export new series. It collects all series of the metric, so bind the labels with With to read
+// a single series repeatedly.
+func lookupLabelValues[T any, M any](l *labeledVec[M], labels T) *dto.Metric {
+	values := labelValues(l.labels, nil, &labels)
+	if !l.labels.allow(values, l.reject) {
+		return &dto.Metric{}
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		l.vec.Collect(ch)
+		close(ch)
+	}()
+
+	var found *dto.Metric
+	for metric := range ch {
+		// Drain the channel even once the series is found, so that Collect returns.
+		if found != nil {
+			continue
+		}
+		if m := readMetric(metric); hasLabelValues(m, l.labels.keys, values) {
+			found = m
+		}
+	}
+	if found == nil {
+		return &dto.Metric{}
+	}
+	return found
+}
+
+// hasLabelValues reports whether the series has the label values for the label keys.
+// Other labels, i.e. constant labels, are ignored.
+func hasLabelValues(m *dto.Metric, keys, values []string) bool {
+	matched := 0
+	for _, lp := range m.GetLabel() {
+		for i, key := range keys {
+			if lp.GetName() != key {
+				continue
+			}
+			if lp.GetValue() != values[i] {
+				return false
+			}
+			matched++
+			break
+		}
+	}
+	return matched == len(keys)
+}
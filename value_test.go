@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	type jobLabels struct {
+		Name   string `label:"name"`
+		Status string `label:"status,values=ok|error"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[jobLabels]("jobs_total", "Jobs.", WithRegistry(reg), WithConstLabels(map[string]string{"queue": "default"}))
+	g := GaugeWith[jobLabels]("jobs_running", "Running jobs.", WithRegistry(reg))
+	unlabeled := Counter("restarts_total", "Restarts.", WithRegistry(reg))
+
+	c.Add(3, jobLabels{Name: "sync", Status: "ok"})
+	c.Inc(jobLabels{Name: "sync", Status: "timeout"}) // Replaced by the fallback value.
+	g.Set(2, jobLabels{Name: "sync"})
+	g.Dec(jobLabels{Name: "sync"})
+	unlabeled.Inc()
+
+	if got := c.Value(jobLabels{Name: "sync", Status: "ok"}); got != 3 {
+		t.Errorf("expected 3, got %v", got)
+	}
+	if got := c.Value(jobLabels{Name: "sync", Status: "other"}); got != 1 {
+		t.Errorf("expected 1 for the fallback value, got %v", got)
+	}
+	if got := g.Value(jobLabels{Name: "sync"}); got != 1 {
+		t.Errorf("expected 1, got %v", got)
+	}
+	bound := c.With(jobLabels{Name: "sync", Status: "ok"})
+	if got := bound.Value(); got != 3 {
+		t.Errorf("expected 3 for bound counter, got %v", got)
+	}
+	if got := unlabeled.Value(); got != 1 {
+		t.Errorf("expected 1, got %v", got)
+	}
+
+	// Reading missing series doesn't create them.
+	if got := c.Value(jobLabels{Name: "async", Status: "ok"}); got != 0 {
+		t.Errorf("expected 0 for missing series, got %v", got)
+	}
+	if body := scrape(t, reg.Handler()); strings.Contains(body, `name="async"`) {
+		t.Errorf("unexpected series created by reading:\n%s", body)
+	}
+}
+
+func TestHistogramSnapshot(t *testing.T) {
+	type jobLabels struct {
+		Name string `label:"name"`
+	}
+
+	reg := NewRegistry()
+	h := HistogramWith[jobLabels]("job_duration_seconds", "Job duration.", []float64{1, 2, 4}, WithRegistry(reg))
+
+	for _, v := range []float64{0.5, 1.5, 1.5, 3, 10} {
+		h.Observe(v, jobLabels{Name: "sync"})
+	}
+
+	s := h.Snapshot(jobLabels{Name: "sync"})
+	if s.Count != 5 || s.Sum != 16.5 {
+		t.Errorf("expected count 5 and sum 16.5, got %v and %v", s.Count, s.Sum)
+	}
+	want := []HistogramBucket{{1, 1}, {2, 3}, {4, 4}, {math.Inf(1), 5}}
+	if len(s.Buckets) != len(want) {
+		t.Fatalf("expected buckets %v, got %v", want, s.Buckets)
+	}
+	for i := range want {
+		if s.Buckets[i] != want[i] {
+			t.Errorf("expected buckets %v, got %v", want, s.Buckets)
+			break
+		}
+	}
+
+	for _, tc := range []struct {
+		q    float64
+		want float64
+	}{
+		{0, 0},
+		{0.2, 1},    // Upper bound of the first bucket.
+		{0.5, 1.75}, // Rank 2.5 of 1..3 in (1, 2].
+		{0.8, 4},
+		{0.99, 4}, // In the +Inf bucket.
+	} {
+		if got := s.Quantile(tc.q); got != tc.want {
+			t.Errorf("expected quantile %v to be %v, got %v", tc.q, tc.want, got)
+		}
+	}
+	if got := s.Quantile(1.5); !math.IsNaN(got) {
+		t.Errorf("expected NaN for invalid quantile, got %v", got)
+	}
+
+	empty := h.Snapshot(jobLabels{Name: "async"})
+	if empty.Count != 0 || !math.IsNaN(empty.Quantile(0.5)) {
+		t.Errorf("expected empty snapshot for missing series, got %+v", empty)
+	}
+
+	bound := h.With(jobLabels{Name: "sync"})
+	if got := bound.Snapshot().Count; got != 5 {
+		t.Errorf("expected count 5 for bound histogram, got %v", got)
+	}
+}
+
+func TestValueConcurrent(t *testing.T) {
+	type workerLabels struct {
+		Worker int `label:"worker"`
+	}
+
+	reg := NewRegistry()
+	c := CounterWith[workerLabels]("work_total", "Work.", WithRegistry(reg))
+	h := HistogramWith[workerLabels]("work_duration_seconds", "Work duration.", []float64{1}, WithRegistry(reg))
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				c.Inc(workerLabels{Worker: i})
+				h.Observe(0.5, workerLabels{Worker: i})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_ = c.Value(workerLabels{Worker: i})
+				if s := h.Snapshot(workerLabels{Worker: i}); len(s.Buckets) > 0 && s.Buckets[0].Count != s.Count {
+					t.Errorf("inconsistent snapshot: %+v", s)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 4 {
+		if got := c.Value(workerLabels{Worker: i}); got != 1000 {
+			t.Errorf("expected 1000 for worker %d, got %v", i, got)
+		}
+	}
+}